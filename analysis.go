@@ -2,82 +2,148 @@ package generate
 
 import (
 	"encoding/json"
-	"errors"
-	"os"
+	"net/url"
+	"path/filepath"
 	"strings"
 )
 
 type AnalysisFile struct {
 	Root bool
 	Path string
+	// URI of the file for the Loader, the one of Path when empty
+	URI string
 }
 
+// AnalysisFiles returns the input files followed by the files they reference, also indirectly, in the order they're
+// found. References anywhere in the schemas are followed, those starting with "/" are relative to rootPath, the other
+// ones to the referencing file. Only the input files are Root.
 func AnalysisFiles(rootPath string, inputFiles []string) ([]AnalysisFile, error) {
-	temp := make(map[string]AnalysisFile)
+	return AnalysisFilesWithLoader(FileLoader{RootPath: rootPath}, inputFiles)
+}
+
+// AnalysisFilesWithLoader is AnalysisFiles reading the files with loader, inputFiles are resolved by it. The
+// references the loader can't read are left to the generator, which fails unless the schema is there already, and so
+// are the references to the $id of a schema read: the input files are read first, so that they're found by $id
+// wherever they're stored.
+func AnalysisFilesWithLoader(loader Loader, inputFiles []string) ([]AnalysisFile, error) {
+	var files []AnalysisFile
+	seen := make(map[string]bool)
+	add := func(uri, path string, root bool) {
+		if !seen[uri] {
+			seen[uri] = true
+			files = append(files, AnalysisFile{Root: root, Path: path, URI: uri})
+		}
+	}
 	for _, file := range inputFiles {
-		temp[file] = AnalysisFile{
-			Root: true,
-			Path: file,
+		uri, ok := loader.Resolve("", file)
+		if !ok {
+			return nil, &SchemaError{File: file, Message: "the input file can't be loaded"}
 		}
+		add(uri, filepath.Clean(file), true)
+	}
 
-		b, err := os.ReadFile(file)
+	// k=$id without fragment
+	ids := make(map[string]bool)
+	read := func(file AnalysisFile) ([]reference, error) {
+		b, err := loader.Load(file.URI)
 		if err != nil {
-			return nil, errors.New("failed to read the input file with error " + err.Error())
+			return nil, &SchemaError{File: file.Path, Message: "failed to read the input file", Err: err}
 		}
-
-		s := &Schema{}
-		err = json.Unmarshal(b, s)
+		docs, err := readDocuments(file.Path, b)
 		if err != nil {
 			return nil, err
 		}
-
-		for _, p := range s.Properties {
-			if p.Reference != "" &&
-				!strings.HasPrefix(p.Reference, "#") {
-				if strings.HasPrefix(p.Reference, "/") {
-					path := rootPath + p.Reference
-					temp[path] = AnalysisFile{
-						Root: false,
-						Path: path,
-					}
-				} else {
-					index := strings.LastIndex(file, "/")
-					path := file[:index+1] + p.Reference
-					temp[path] = AnalysisFile{
-						Root: false,
-						Path: path,
-					}
-				}
+		base, err := url.Parse(file.URI)
+		if err != nil {
+			return nil, &SchemaError{File: file.Path, Message: "invalid document URI", Err: err}
+		}
+		var refs []reference
+		for _, doc := range docs {
+			s := &Schema{}
+			if err := json.Unmarshal(doc.JSON, s); err != nil {
+				// ReadInputFiles reports the error with its position in the file
+				continue
 			}
+			refs = append(refs, externalReferences(s, base, ids)...)
 		}
+		return refs, nil
+	}
+	refs := make([][]reference, len(files))
+	for i, file := range files {
+		r, err := read(file)
+		if err != nil {
+			return nil, err
+		}
+		refs[i] = r
+	}
 
-		for _, d := range s.Definitions {
-			for _, p := range d.Properties {
-				if p.Reference != "" &&
-					!strings.HasPrefix(p.Reference, "#") {
-					if strings.HasPrefix(p.Reference, "/") {
-						path := rootPath + p.Reference
-						temp[path] = AnalysisFile{
-							Root: false,
-							Path: path,
-						}
-					} else {
-						index := strings.LastIndex(file, "/")
-						path := file[:index+1] + p.Reference
-						temp[path] = AnalysisFile{
-							Root: false,
-							Path: path,
-						}
-					}
-				}
+	// the files appended while reading are read in turn, until there are no new ones
+	for i := 0; i < len(files); i++ {
+		file := files[i]
+		if i >= len(refs) {
+			r, err := read(file)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, r)
+		}
+		for _, ref := range refs[i] {
+			if ids[ref.uri] {
+				continue
+			}
+			if uri, ok := loader.Resolve(file.URI, ref.ref); ok {
+				add(uri, documentPath(uri), false)
 			}
 		}
 	}
 
-	paths := make([]AnalysisFile, 0, len(temp))
-	for _, v := range temp {
-		paths = append(paths, v)
+	return files, nil
+}
+
+// reference is a reference to another document.
+type reference struct {
+	ref string
+	// uri of the document resolved against the $id of the referencing schema
+	uri string
+}
+
+// externalReferences returns the references of schema and of its sub-schemas to other documents, base is the URI the
+// references of the parent of schema resolve against. The $id of the schemas are added to ids.
+func externalReferences(schema *Schema, base *url.URL, ids map[string]bool) []reference {
+	if id := schema.ID(); id != "" && !strings.HasPrefix(id, "#") {
+		if u, err := url.Parse(id); err == nil {
+			base = base.ResolveReference(u)
+			base.Fragment = ""
+			ids[base.String()] = true
+		}
+	}
+	var refs []reference
+	if schema.Reference != "" && !strings.HasPrefix(schema.Reference, "#") {
+		if u, err := url.Parse(schema.Reference); err == nil {
+			u = base.ResolveReference(u)
+			u.Fragment = ""
+			refs = append(refs, reference{ref: schema.Reference, uri: u.String()})
+		}
+	}
+	for _, sub := range schema.subSchemas() {
+		refs = append(refs, externalReferences(sub, base, ids)...)
 	}
+	return refs
+}
 
-	return paths, nil
+// referencedFile returns the path of the file referenced by ref from file, "" when ref isn't a file.
+func referencedFile(rootPath, file, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	switch {
+	case u.Scheme == "file":
+		return filepath.FromSlash(u.Path)
+	case u.Scheme != "" || u.Host != "":
+		return ""
+	case strings.HasPrefix(u.Path, "/"):
+		return rootPath + u.Path
+	}
+	return filepath.Join(filepath.Dir(file), filepath.FromSlash(u.Path))
 }
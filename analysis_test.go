@@ -0,0 +1,71 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestThatReferencedFilesAreFoundTransitively(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"root.json":     `{"properties": {"list": {"items": {"oneOf": [{"$ref": "types/a.json"}, {"type": "null"}]}}}}`,
+		"types/a.json":  `{"additionalProperties": {"$ref": "b.yaml#/definitions/b"}}`,
+		"types/b.yaml":  "definitions:\n  b:\n    allOf:\n      - $ref: ../root.json\n      - $ref: /shared/c.json\n",
+		"shared/c.json": `{"type": "object", "properties": {"a": {"$ref": "../types/a.json"}, "web": {"$ref": "https://example.com/d.json"}}}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	analysisFiles, err := AnalysisFiles(dir, []string{filepath.Join(dir, "root.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected []AnalysisFile
+	for i, name := range []string{"root.json", "types/a.json", "types/b.yaml", "shared/c.json"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		expected = append(expected, AnalysisFile{Root: i == 0, Path: path, URI: "file://" + filepath.ToSlash(path)})
+	}
+	if !reflect.DeepEqual(analysisFiles, expected) {
+		t.Errorf("expected %v, got %v", expected, analysisFiles)
+	}
+}
+
+func TestThatReferencesToTheIDOfAnInputFileArentFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"b/order.json":    `{"$id": "https://example.com/orders/order.json", "properties": {"customer": {"$ref": "../customer.json"}}}`,
+		"a/customer.json": `{"$id": "https://example.com/customer.json", "properties": {"note": {"$ref": "note.json"}}}`,
+		"a/note.json":     `{"type": "string"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	analysisFiles, err := AnalysisFiles(dir, []string{filepath.Join(dir, "b", "order.json"), filepath.Join(dir, "a", "customer.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range analysisFiles {
+		paths = append(paths, f.Path)
+	}
+	expected := []string{filepath.Join(dir, "b", "order.json"), filepath.Join(dir, "a", "customer.json"), filepath.Join(dir, "a", "note.json")}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected %v, got %v", expected, paths)
+	}
+}
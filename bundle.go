@@ -0,0 +1,297 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Bundle returns the schema of file as a single document: the external schemas it references, also indirectly, are
+// inlined under $defs (definitions before draft 2019-09) and every $ref is rewritten to a local JSON pointer. An
+// inlined schema is named after its file, numbered when the name is taken already, and loses its $schema and $id.
+// References starting with "/" are relative to rootPath like for AnalysisFiles. The schema ends with a newline.
+func Bundle(rootPath, file string) ([]byte, error) {
+	return BundleWithOptions(file, ParseOptions{Loader: FileLoader{RootPath: rootPath}})
+}
+
+// BundleWithOptions is Bundle reading the documents with ParseOptions.Loader, FileLoader when it's nil. The documents
+// following the first one of a YAML file are referenced by their index, e.g. schemas.yaml?document=1.
+func BundleWithOptions(file string, opts ParseOptions) ([]byte, error) {
+	loader := opts.Loader
+	if loader == nil {
+		loader = FileLoader{}
+	}
+	uri, ok := loader.Resolve("", file)
+	if !ok {
+		return nil, &SchemaError{File: file, Message: "the input file can't be loaded"}
+	}
+	b := &bundler{
+		loader: loader,
+		names:  map[string]string{uri: ""},
+		ids:    make(map[string]string),
+		used:   make(map[string]bool),
+	}
+	root, err := b.readDocument(uri)
+	if err != nil {
+		return nil, err
+	}
+	b.keyword = bundleDefinitionsKeyword(root)
+	b.defs, _ = root.values[b.keyword].(*orderedObject)
+	if b.defs == nil {
+		b.defs = newOrderedObject()
+	}
+	for _, key := range b.defs.keys {
+		b.used[key] = true
+	}
+	b.addID(root, uri)
+	if err := b.rewrite(root, uri, "", "#", false); err != nil {
+		return nil, err
+	}
+	if len(b.defs.keys) > 0 {
+		root.set(b.keyword, b.defs)
+	}
+
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// bundler inlines the external schemas of a document.
+type bundler struct {
+	loader Loader
+	// keyword holding the inlined schemas, $defs or definitions
+	keyword string
+	defs    *orderedObject
+	// names of the documents in defs, "" for the bundled one; k=document URI
+	names map[string]string
+	// URIs of the documents with an $id; k=$id without fragment
+	ids map[string]string
+	// names taken in defs
+	used map[string]bool
+}
+
+// bundleDefinitionsKeyword returns the keyword of the re-usable schemas of the document root: the one it uses
+// already, or the one of its draft.
+func bundleDefinitionsKeyword(root *orderedObject) string {
+	for _, keyword := range []string{"$defs", "definitions"} {
+		if _, ok := root.values[keyword].(*orderedObject); ok {
+			return keyword
+		}
+	}
+	schemaType, _ := root.values["$schema"].(string)
+	switch (&Schema{SchemaType: schemaType}).Draft() {
+	case Draft04, Draft06, Draft07:
+		return "definitions"
+	}
+	return "$defs"
+}
+
+// readDocument reads the schema at uri, the document of a YAML file selected by the query document=N, the first one
+// without.
+func (b *bundler) readDocument(uri string) (*orderedObject, error) {
+	file, index, err := splitDocumentURI(uri)
+	if err != nil {
+		return nil, &SchemaError{File: uri, Message: "invalid document URI", Err: err}
+	}
+	path := documentPath(file)
+	data, err := b.loader.Load(file)
+	if err != nil {
+		return nil, &SchemaError{File: path, Message: "failed to read the input file", Err: err}
+	}
+	docs, err := readDocuments(path, data)
+	if err != nil {
+		return nil, err
+	}
+	if index >= len(docs) {
+		return nil, &SchemaError{File: path, Message: fmt.Sprintf("no document %d in the file", index)}
+	}
+	v, err := decodeOrdered(docs[index].JSON)
+	if err != nil {
+		return nil, &SchemaError{File: path, Message: "failed to parse the schema", Err: err}
+	}
+	doc, ok := v.(*orderedObject)
+	if !ok {
+		return nil, &SchemaError{File: path, Message: "the schema isn't an object"}
+	}
+	return doc, nil
+}
+
+// splitDocumentURI returns the URI of the file of the document at uri and the index of the document in the file,
+// see ReadInputFiles.
+func splitDocumentURI(uri string) (file string, index int, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", 0, err
+	}
+	if document := u.Query().Get("document"); document != "" {
+		if index, err = strconv.Atoi(document); err != nil || index < 0 {
+			return "", 0, fmt.Errorf("invalid document index %q", document)
+		}
+	}
+	u.RawQuery = ""
+	return u.String(), index, nil
+}
+
+// addID records the $id of the document at uri, so that absolute references to it are inlined too.
+func (b *bundler) addID(doc *orderedObject, uri string) {
+	for _, keyword := range []string{"$id", "id"} {
+		if id, ok := doc.values[keyword].(string); ok {
+			if u, err := url.Parse(id); err == nil {
+				u.Fragment = ""
+				b.ids[u.String()] = uri
+			}
+		}
+	}
+}
+
+// nameKeywords hold schemas keyed by names rather than keywords.
+var nameKeywords = map[string]bool{
+	"$defs":             true,
+	"definitions":       true,
+	"properties":        true,
+	"patternProperties": true,
+	"dependentSchemas":  true,
+	"dependencies":      true,
+}
+
+// valueKeywords hold JSON values rather than schemas, their references aren't rewritten.
+var valueKeywords = map[string]bool{
+	"enum":     true,
+	"const":    true,
+	"default":  true,
+	"examples": true,
+	"example":  true,
+}
+
+// rewrite rewrites the references in v, a value of the document at uri, prefix is the pointer of the document in the
+// bundle and pointer the one of v in the document. The keys of v are names rather than keywords when names is set.
+func (b *bundler) rewrite(v any, uri, prefix, pointer string, names bool) error {
+	switch v := v.(type) {
+	case *orderedObject:
+		// inlined schemas are appended to the keys of the bundled $defs
+		for _, key := range append([]string(nil), v.keys...) {
+			child := pointer + "/" + escapeJSONPointer(key)
+			if !names {
+				if valueKeywords[key] {
+					continue
+				}
+				if ref, ok := v.values[key].(string); ok && key == "$ref" {
+					local, err := b.localReference(ref, uri, prefix)
+					if err != nil {
+						return &SchemaError{File: documentPath(uri), Pointer: pointer, Keyword: "$ref", Message: "failed to inline the reference", Err: err}
+					}
+					v.set(key, local)
+					continue
+				}
+			}
+			if err := b.rewrite(v.values[key], uri, prefix, child, !names && nameKeywords[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, item := range v {
+			if err := b.rewrite(item, uri, prefix, pointer+"/"+strconv.Itoa(i), false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// localReference returns the reference to ref, a reference of the document at uri, in the bundle.
+func (b *bundler) localReference(ref, uri, prefix string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	if u.Scheme == "" && u.Host == "" && u.Path == "" && u.RawQuery == "" {
+		return localPointer(prefix, u.EscapedFragment()), nil
+	}
+	target := b.targetURI(u, uri)
+	if target == "" {
+		return "", fmt.Errorf("the external reference %q can't be loaded", ref)
+	}
+	name, err := b.include(target)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return localPointer("", u.EscapedFragment()), nil
+	}
+	return localPointer("/"+b.keyword+"/"+escapeJSONPointer(name), u.EscapedFragment()), nil
+}
+
+// localPointer returns the reference to fragment in the document at prefix, anchors are kept as they are.
+func localPointer(prefix, fragment string) string {
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		return "#" + fragment
+	}
+	return "#" + prefix + fragment
+}
+
+// targetURI returns the URI of the document referenced by u from the document at uri, "" when it's neither a
+// document with a known $id nor one the loader reads.
+func (b *bundler) targetURI(u *url.URL, uri string) string {
+	document := *u
+	document.Fragment = ""
+	if target, ok := b.ids[document.String()]; ok {
+		return target
+	}
+	// the index of a YAML document isn't part of its file
+	file, _, err := splitDocumentURI(uri)
+	if err != nil {
+		return ""
+	}
+	document.RawQuery = ""
+	target := file
+	if document.String() != "" {
+		var ok bool
+		if target, ok = b.loader.Resolve(uri, document.String()); !ok {
+			return ""
+		}
+	}
+	// the first document is addressed by the file, see ReadInputFiles
+	if index := u.Query().Get("document"); index != "" && index != "0" {
+		target += "?document=" + index
+	}
+	return target
+}
+
+// include inlines the document at uri unless it's there already and returns its name.
+func (b *bundler) include(uri string) (string, error) {
+	if name, ok := b.names[uri]; ok {
+		return name, nil
+	}
+	doc, err := b.readDocument(uri)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	base := path.Base(u.Path)
+	base = strings.TrimSuffix(base, path.Ext(base))
+	name := base
+	for i := 2; b.used[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	b.used[name] = true
+	b.names[uri] = name
+	b.addID(doc, uri)
+	// the bundle is a single resource
+	doc.delete("$schema")
+	doc.delete("$id")
+	doc.delete("id")
+	b.defs.set(name, doc)
+	return name, b.rewrite(doc, uri, "/"+b.keyword+"/"+escapeJSONPointer(name), "#", false)
+}
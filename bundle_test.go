@@ -0,0 +1,127 @@
+package generate
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestThatExternalReferencesAreBundled(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"order.json": `{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "title": "Order",
+    "type": "object",
+    "properties": {
+        "address": { "$ref": "common/address.json" },
+        "street": { "$ref": "common/address.json#/$defs/street" },
+        "customer": { "$ref": "customer.yaml" }
+    },
+    "$defs": {
+        "address": { "type": "string" }
+    }
+}`,
+		"common/address.json": `{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "$id": "https://example.com/address.json",
+    "type": "object",
+    "properties": {
+        "street": { "$ref": "#/$defs/street" },
+        "owner": { "$ref": "../customer.yaml" }
+    },
+    "$defs": {
+        "street": { "type": "string" }
+    }
+}`,
+		"customer.yaml": "type: object\nproperties:\n  home:\n    $ref: https://example.com/address.json\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := Bundle(dir, filepath.Join(dir, "order.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := Parse(string(b), &url.URL{Scheme: "file", Path: "/bundle.json"})
+	if err != nil {
+		t.Fatalf("expected a valid schema, got %v:\n%s", err, b)
+	}
+
+	expected := map[string]string{
+		"#/properties/address":               "#/$defs/address2",
+		"#/properties/street":                "#/$defs/address2/$defs/street",
+		"#/properties/customer":              "#/$defs/customer",
+		"#/$defs/address2/properties/street": "#/$defs/address2/$defs/street",
+		"#/$defs/address2/properties/owner":  "#/$defs/customer",
+		"#/$defs/customer/properties/home":   "#/$defs/address2",
+	}
+	for pointer, ref := range expected {
+		s, ok := root.schemaAtPointer(strings.TrimPrefix(pointer, "#"))
+		if !ok {
+			t.Errorf("expected a schema at %s:\n%s", pointer, b)
+			continue
+		}
+		if s.Reference != ref {
+			t.Errorf("expected %s to reference %s, got %q", pointer, ref, s.Reference)
+		}
+	}
+	if keys := root.definitionKeys("$defs"); len(keys) != 3 {
+		t.Errorf("expected the external schemas after the local ones, got %v", keys)
+	}
+	if address := root.Definitions["address2"]; address.ID() != "" || address.SchemaType != "" {
+		t.Errorf("expected the inlined schema to lose its $id and $schema, got %q %q", address.ID(), address.SchemaType)
+	}
+}
+
+func TestThatBundlesAreReadWithTheLoader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"order.json": {Data: []byte(`{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "type": "object",
+    "properties": {
+        "item": { "$ref": "types.yaml" },
+        "customer": { "$ref": "types.yaml?document=1" }
+    }
+}`)},
+		"types.yaml": {Data: []byte("type: string\n---\ntype: object\nproperties:\n  name:\n    $ref: '?document=0'\n")},
+	}
+
+	b, err := BundleWithOptions("order.json", ParseOptions{Loader: FSLoader{FS: fsys}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := Parse(string(b), &url.URL{Scheme: "file", Path: "/bundle.json"})
+	if err != nil {
+		t.Fatalf("expected a valid schema, got %v:\n%s", err, b)
+	}
+
+	expected := map[string]string{
+		"#/properties/item":              "#/$defs/types",
+		"#/properties/customer":          "#/$defs/types2",
+		"#/$defs/types2/properties/name": "#/$defs/types",
+	}
+	for pointer, ref := range expected {
+		s, ok := root.schemaAtPointer(strings.TrimPrefix(pointer, "#"))
+		if !ok {
+			t.Errorf("expected a schema at %s:\n%s", pointer, b)
+			continue
+		}
+		if s.Reference != ref {
+			t.Errorf("expected %s to reference %s, got %q", pointer, ref, s.Reference)
+		}
+	}
+	if customer := root.Definitions["types2"]; customer == nil || customer.TypeValue != "object" {
+		t.Errorf("expected the second YAML document to be inlined, got:\n%s", b)
+	}
+}
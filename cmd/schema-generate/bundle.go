@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Graff913/generate-go-json-schema"
+)
+
+// bundleCommand writes a schema with its external references inlined, "schema-generate bundle [flags] schema".
+func bundleCommand(args []string) {
+	flags := flag.NewFlagSet("bundle", flag.ExitOnError)
+	o := flags.String("o", "", "The output file for the schema.")
+	rootPath := flags.String("r", "", "The root path repo, absolute references are relative to it.")
+	mirrors := prefixes{}
+	flags.Var(mirrors, "map", "A URI prefix and the directory of its local mirror, e.g. https://schemas.example.com/=./schemas/. Can be repeated.")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s bundle:\n", os.Args[0])
+		flags.PrintDefaults()
+		_, _ = fmt.Fprintln(os.Stderr, "  schema")
+		_, _ = fmt.Fprintln(os.Stderr, "\tThe JSON Schema file to bundle, in JSON or YAML.")
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	var loader generate.Loader = generate.FileLoader{RootPath: *rootPath}
+	if len(mirrors) > 0 {
+		loader = generate.MapLoader{Loader: loader, Prefixes: mirrors}
+	}
+	schema, err := generate.BundleWithOptions(flags.Arg(0), generate.ParseOptions{Loader: loader})
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *o == "" {
+		_, _ = os.Stdout.Write(schema)
+		return
+	}
+	if err := os.WriteFile(*o, schema, 0o644); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "Error writing output file: ", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Graff913/generate-go-json-schema"
+)
+
+// inferCommand writes the JSON schema of sample JSON documents, "schema-generate infer [flags] samples".
+func inferCommand(args []string) {
+	flags := flag.NewFlagSet("infer", flag.ExitOnError)
+	o := flags.String("o", "", "The output file for the schema.")
+	title := flags.String("title", "", "The title of the schema, the name of the root type.")
+	maxEnumValues := flags.Int("enum", 10, "The most distinct values of strings making them an enum, 0 for none.")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s infer:\n", os.Args[0])
+		flags.PrintDefaults()
+		_, _ = fmt.Fprintln(os.Stderr, "  samples")
+		_, _ = fmt.Fprintln(os.Stderr, "\tThe sample JSON files, each with one document or one per line (NDJSON).")
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	schema, err := generate.InferFiles(flags.Args(), generate.InferOptions{
+		Title:         *title,
+		MaxEnumValues: *maxEnumValues,
+	})
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *o == "" {
+		_, _ = os.Stdout.Write(schema)
+		return
+	}
+	if err := os.WriteFile(*o, schema, 0o644); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "Error writing output file: ", err)
+		os.Exit(1)
+	}
+}
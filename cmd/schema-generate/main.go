@@ -1,5 +1,8 @@
 // The schema-generate binary reads the JSON schema files passed as arguments
-// and outputs the corresponding Go structs.
+// and outputs the corresponding Go structs. "schema-generate reflect" does the
+// reverse, it outputs the JSON schema of Go types, "schema-generate bundle"
+// outputs a schema with its external references inlined and "schema-generate
+// infer" the schema of sample JSON documents.
 package main
 
 import (
@@ -7,6 +10,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/Graff913/generate-go-json-schema"
 )
@@ -16,19 +22,62 @@ var (
 	p                     = flag.String("p", "main", "The package that the structs are created in.")
 	bson                  = flag.Bool("bson", false, "Generate bson tags")
 	omitempty             = flag.Bool("omitempty", false, "Generate omitempty tags")
+	validate              = flag.Bool("validate", false, "Generate Validate() methods checking the validation keywords")
+	order                 = flag.String("order", "alphabetical", "The order of the types and struct fields: alphabetical, source or required (source order, required fields first).")
 	rootPath              = flag.String("r", "", "The root path repo")
 	i                     = flag.String("i", "", "A single file path (used for backwards compatibility).")
 	schemaKeyRequiredFlag = flag.Bool("schemaKeyRequired", false, "Allow input files with no $schema key.")
+	strict                = flag.Bool("strict", false, "Fail on schema warnings, e.g. a $schema keyword below the root.")
+	nullable              = flag.Bool("nullable", false, "Generate Nullable[T] for values that can be null, telling null and absent apart, instead of pointers.")
+	formats               = flag.String("formats", "", "A JSON file mapping format names to Go types, added to the built-in ones.")
+	packageDir            = flag.String("d", ".", "The directory the packages of -package are written to, each in the directory of its name.")
+	mirrors               = prefixes{}
+	packages              = prefixes{}
 )
 
+// prefixes are the -map and -package flags, prefixes mapped to directories or import paths.
+type prefixes map[string]string
+
+func (m prefixes) String() string {
+	return fmt.Sprint(map[string]string(m))
+}
+
+func (m prefixes) Set(value string) error {
+	prefix, v, ok := strings.Cut(value, "=")
+	if !ok || prefix == "" {
+		return fmt.Errorf("expected prefix=value, got %q", value)
+	}
+	m[prefix] = v
+	return nil
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reflect":
+			reflectCommand(os.Args[2:])
+			return
+		case "bundle":
+			bundleCommand(os.Args[2:])
+			return
+		case "infer":
+			inferCommand(os.Args[2:])
+			return
+		}
+	}
+
 	flag.Usage = func() {
 		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
 		flag.PrintDefaults()
 		_, _ = fmt.Fprintln(os.Stderr, "  paths")
-		_, _ = fmt.Fprintln(os.Stderr, "\tThe input JSON Schema files.")
+		_, _ = fmt.Fprintln(os.Stderr, "\tThe input JSON Schema files, in JSON or YAML.")
+		_, _ = fmt.Fprintf(os.Stderr, "\n%s reflect -h prints the usage of the Go to JSON schema mode.\n", os.Args[0])
+		_, _ = fmt.Fprintf(os.Stderr, "%s bundle -h prints the usage of the mode inlining external references.\n", os.Args[0])
+		_, _ = fmt.Fprintf(os.Stderr, "%s infer -h prints the usage of the mode inferring a schema from samples.\n", os.Args[0])
 	}
 
+	flag.Var(mirrors, "map", "A URI prefix and the directory of its local mirror, e.g. https://schemas.example.com/=./schemas/. Can be repeated.")
+	flag.Var(packages, "package", "A schema path or URI prefix and the import path of the Go package of its types, e.g. schemas/common=example.com/api/common. Can be repeated.")
 	flag.Parse()
 
 	inputFiles := flag.Args()
@@ -41,25 +90,113 @@ func main() {
 		os.Exit(1)
 	}
 
-	analysisFiles, err := generate.AnalysisFiles(*rootPath, inputFiles)
+	fieldOrder, err := generate.ParseOrder(*order)
 	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, err.Error())
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	schemas, err := generate.ReadInputFiles(analysisFiles, *schemaKeyRequiredFlag)
+	var loader generate.Loader = generate.FileLoader{RootPath: *rootPath}
+	if len(mirrors) > 0 {
+		loader = generate.MapLoader{Loader: loader, Prefixes: mirrors}
+	}
+
+	analysisFiles, err := generate.AnalysisFilesWithLoader(loader, inputFiles)
 	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, err.Error())
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	schemas, diagnostics, err := generate.ReadInputFilesWithOptions(analysisFiles, generate.ParseOptions{
+		SchemaKeyRequired: *schemaKeyRequiredFlag,
+		Strict:            *strict,
+		Loader:            loader,
+	})
+	for _, d := range diagnostics {
+		if d.Severity == generate.SeverityWarning && !*strict {
+			_, _ = fmt.Fprintln(os.Stderr, d.String())
+		}
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
 	g := generate.New(schemas...)
+	g.GenericNullable = *nullable
+	g.Loader = loader
+	if len(packages) > 0 {
+		g.Packages = make(map[string]string, len(packages))
+		for prefix, importPath := range packages {
+			// paths are matched against the absolute paths of the files
+			if !strings.Contains(prefix, "://") {
+				abs, err := filepath.Abs(prefix)
+				if err != nil {
+					_, _ = fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				if strings.HasSuffix(prefix, "/") || strings.HasSuffix(prefix, string(filepath.Separator)) {
+					abs += string(filepath.Separator)
+				}
+				prefix = abs
+			}
+			g.Packages[prefix] = importPath
+		}
+	}
+	if *formats != "" {
+		g.Formats, err = generate.LoadFormats(*formats)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 
 	err = g.CreateTypes(*rootPath, *p, *bson)
 	if err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, "Failure generating structs: ", err)
 		os.Exit(1)
 	}
+	for _, d := range g.Diagnostics {
+		if d.Severity == generate.SeverityWarning && !*strict {
+			_, _ = fmt.Fprintln(os.Stderr, d.String())
+		}
+	}
+	if err := g.Diagnostics.Err(*strict); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	outputOptions := generate.OutputOptions{
+		BSON:         *bson,
+		TagOmitempty: *omitempty,
+		Validate:     *validate,
+		Order:        fieldOrder,
+	}
+	for _, importPath := range g.PackagePaths() {
+		if importPath == "" {
+			continue
+		}
+		dir := filepath.Join(*packageDir, path.Base(importPath))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, "Error creating the package directory: ", err)
+			os.Exit(1)
+		}
+		f, err := os.Create(filepath.Join(dir, path.Base(importPath)+".go"))
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, "Error opening output file: ", err)
+			os.Exit(1)
+		}
+		generate.OutputPackage(f, g, importPath, outputOptions)
+		if err := f.Close(); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	// the input files may all be in packages of their own
+	if len(packages) > 0 && !contains(g.PackagePaths(), "") {
+		return
+	}
 
 	var w io.Writer = os.Stdout
 
@@ -72,5 +209,14 @@ func main() {
 		}
 	}
 
-	generate.Output(w, g, *p, *bson, *omitempty)
+	generate.OutputWithOptions(w, g, *p, outputOptions)
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
 }
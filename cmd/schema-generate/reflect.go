@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Graff913/generate-go-json-schema"
+)
+
+// reflectCommand writes the JSON schema of Go types, "schema-generate reflect [flags] [packages]".
+func reflectCommand(args []string) {
+	flags := flag.NewFlagSet("reflect", flag.ExitOnError)
+	o := flags.String("o", "", "The output file for the schema.")
+	typeNames := flags.String("type", "", "The comma separated names of the types, all the exported types when empty.")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s reflect:\n", os.Args[0])
+		flags.PrintDefaults()
+		_, _ = fmt.Fprintln(os.Stderr, "  packages")
+		_, _ = fmt.Fprintln(os.Stderr, "\tThe Go packages declaring the types, the current directory by default.")
+	}
+	_ = flags.Parse(args)
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	var opts generate.ReflectOptions
+	if *typeNames != "" {
+		opts.Types = strings.Split(*typeNames, ",")
+	}
+
+	schema, err := generate.Reflect(opts, patterns...)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *o == "" {
+		_, _ = os.Stdout.Write(schema)
+		return
+	}
+	if err := os.WriteFile(*o, schema, 0o644); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "Error writing output file: ", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,240 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity tells whether a Diagnostic prevents generating types.
+type Severity int
+
+const (
+	// SeverityWarning is a problem the generator works around, it fails in strict mode only.
+	SeverityWarning Severity = iota
+	// SeverityError is a problem the generator can't work around.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Diagnostic is a problem of a schema found by Schema.Init.
+type Diagnostic struct {
+	Severity Severity
+	*SchemaError
+}
+
+func (d Diagnostic) String() string {
+	return d.Severity.String() + ": " + d.Error()
+}
+
+// Diagnostics are the problems of one or more schemas.
+type Diagnostics []Diagnostic
+
+// Err returns the errors joined, including the warnings when strict. It returns nil when there are none.
+func (d Diagnostics) Err(strict bool) error {
+	var errs []error
+	for _, diagnostic := range d {
+		if strict || diagnostic.Severity == SeverityError {
+			errs = append(errs, diagnostic.SchemaError)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ParseOptions configure ParseWithOptions.
+type ParseOptions struct {
+	// SchemaKeyRequired rejects documents without a $schema key.
+	SchemaKeyRequired bool
+	// Strict makes warnings fail like errors.
+	Strict bool
+	// Loader reads the input files, FileLoader when nil.
+	Loader Loader
+}
+
+// instanceTypes are the valid values of "type".
+var instanceTypes = []string{"array", "boolean", "integer", "null", "number", "object", "string"}
+
+// diagnose returns the problems of the schema tree below root.
+func (root *Schema) diagnose() Diagnostics {
+	var d Diagnostics
+	ids := make(map[string]*Schema)
+	base, _ := root.baseURI()
+	if base == nil {
+		base = &url.URL{}
+	}
+	root.diagnoseSchema(&d, ids, *base)
+	d.sort()
+	return d
+}
+
+// sort orders the diagnostics as found in the document, the sub-schemas are visited in map order.
+func (d Diagnostics) sort() {
+	sort.SliceStable(d, func(i, j int) bool {
+		if d[i].Line != d[j].Line {
+			return d[i].Line < d[j].Line
+		}
+		if d[i].Column != d[j].Column {
+			return d[i].Column < d[j].Column
+		}
+		return d[i].Pointer < d[j].Pointer
+	})
+}
+
+// merge adds the diagnostics of other, except those of a keyword d already has a problem with.
+func (d Diagnostics) merge(other Diagnostics) Diagnostics {
+	known := make(map[string]bool)
+	for _, diagnostic := range d {
+		known[diagnostic.Pointer+" "+diagnostic.Keyword] = true
+	}
+	for _, diagnostic := range other {
+		if !known[diagnostic.Pointer+" "+diagnostic.Keyword] {
+			d = append(d, diagnostic)
+		}
+	}
+	d.sort()
+	return d
+}
+
+func (schema *Schema) diagnoseSchema(d *Diagnostics, ids map[string]*Schema, base url.URL) {
+	add := func(severity Severity, keyword, message string, cause error) {
+		*d = append(*d, Diagnostic{Severity: severity, SchemaError: newSchemaError(schema, keyword, message, cause)})
+	}
+
+	if !schema.IsRoot() {
+		// $schema is only allowed at the root and, from 2019-09 onwards, in embedded resources with an $id
+		if schema.SchemaType != "" && (schema.ID() == "" || schema.GetRoot().Draft() < Draft201909) {
+			add(SeverityWarning, "$schema", "$schema is ignored below the root of the document", nil)
+		}
+		if id := schema.ID(); id != "" {
+			if ref, err := url.Parse(id); err != nil {
+				add(SeverityError, "$id", fmt.Sprintf("invalid $id %q", id), err)
+			} else {
+				resolved := base.ResolveReference(ref)
+				if other, ok := ids[resolved.String()]; ok && other != schema {
+					add(SeverityError, "$id", fmt.Sprintf("duplicate $id %q, also used at %s", id, schemaPointer(other)), nil)
+				}
+				ids[resolved.String()] = schema
+				if !strings.HasPrefix(id, "#") {
+					base = *resolved
+					base.Fragment = ""
+				}
+			}
+		}
+	}
+
+	if schema.Reference != "" {
+		if ref, err := url.Parse(schema.Reference); err != nil {
+			add(SeverityError, "$ref", fmt.Sprintf("invalid reference %q", schema.Reference), err)
+		} else if ref.Scheme == "" && ref.Host == "" && ref.Path == "" && strings.HasPrefix(ref.Fragment, "/") {
+			if _, ok := schema.GetRoot().schemaAtPointer(ref.Fragment); !ok {
+				add(SeverityWarning, "$ref", fmt.Sprintf("reference %q not found in the document", schema.Reference), nil)
+			}
+		}
+	}
+
+	var types []interface{}
+	switch t := schema.TypeValue.(type) {
+	case string:
+		types = []interface{}{t}
+	case []interface{}:
+		types = t
+	case nil:
+	default:
+		add(SeverityError, "type", fmt.Sprintf("invalid type %v", t), nil)
+	}
+	for _, t := range types {
+		if s, ok := t.(string); !ok || !contains(instanceTypes, s) {
+			add(SeverityError, "type", fmt.Sprintf("unknown type %v, expected one of %s", t, strings.Join(instanceTypes, ", ")), nil)
+		}
+	}
+
+	*d = append(*d, schema.diagnoseExtensions()...)
+
+	// in the order of the document, the later of two schemas with the same $id is the duplicate
+	for _, sub := range schema.subSchemas() {
+		sub.diagnoseSchema(d, ids, base)
+	}
+}
+
+// schemaAtPointer returns the sub-schema at the JSON pointer, e.g. /$defs/address.
+func (schema *Schema) schemaAtPointer(pointer string) (*Schema, bool) {
+	current := schema
+	segments := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i := 0; i < len(segments); i++ {
+		segment := strings.NewReplacer("~1", "/", "~0", "~").Replace(segments[i])
+		var next *Schema
+		switch segment {
+		case "items":
+			if current.Items != nil {
+				next = current.Items
+			} else if i+1 < len(segments) && itemAt(current.PrefixItems, segments[i+1]) != nil {
+				next = itemAt(current.PrefixItems, segments[i+1])
+				i++
+			} else if current.AdditionalItems != nil {
+				// "items" next to "prefixItems" are the items following the tuple (2020-12)
+				next = (*Schema)(current.AdditionalItems)
+			}
+		case "prefixItems":
+			if i+1 < len(segments) {
+				next = itemAt(current.PrefixItems, segments[i+1])
+				i++
+			}
+		case "additionalProperties":
+			next = (*Schema)(current.AdditionalProperties)
+		case "additionalItems":
+			next = (*Schema)(current.AdditionalItems)
+		case "propertyNames":
+			next = current.PropertyNames
+		case "contains":
+			next = current.Contains
+		case "if", "then", "else":
+			next = current.conditionsByKeyword()[segment]
+		case "allOf", "anyOf", "oneOf":
+			if i+1 < len(segments) {
+				next = itemAt(current.combinatorsByKeyword()[segment], segments[i+1])
+				i++
+			}
+		default:
+			// keywords with schemas by name
+			var named map[string]*Schema
+			switch segment {
+			case "properties":
+				named = current.Properties
+			case "patternProperties":
+				named = current.PatternProperties
+			default:
+				named = current.definitionsByKeyword()[segment]
+			}
+			if segment == "components" && i+1 < len(segments) && segments[i+1] == "schemas" {
+				named = current.definitionsByKeyword()["components/schemas"]
+				i++
+			}
+			if named != nil && i+1 < len(segments) {
+				next = named[strings.NewReplacer("~1", "/", "~0", "~").Replace(segments[i+1])]
+				i++
+			}
+		}
+		if next == nil {
+			return nil, false
+		}
+		current = next
+	}
+	return current, true
+}
+
+func itemAt(schemas []*Schema, segment string) *Schema {
+	i, err := strconv.Atoi(segment)
+	if err != nil || i < 0 || i >= len(schemas) {
+		return nil
+	}
+	return schemas[i]
+}
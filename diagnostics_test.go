@@ -0,0 +1,157 @@
+package generate
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestThatInitReportsDiagnostics(t *testing.T) {
+	doc := `{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "type": "object",
+    "properties": {
+        "name": {
+            "$schema": "http://json-schema.org/draft-07/schema#",
+            "type": "string"
+        },
+        "address": {
+            "$ref": "#/definitions/Address"
+        },
+        "a": {
+            "$id": "#item",
+            "type": "strin"
+        },
+        "b": {
+            "$id": "#item",
+            "type": "integer"
+        }
+    }
+}`
+	_, diagnostics, err := ParseWithOptions(doc, &url.URL{Scheme: "file", Path: "/diagnostics.json"}, ParseOptions{})
+	if err == nil {
+		t.Fatal("expected the errors to fail parsing")
+	}
+
+	expected := []struct {
+		severity Severity
+		line     int
+		pointer  string
+		keyword  string
+	}{
+		{SeverityWarning, 6, "#/properties/name", "$schema"},
+		{SeverityWarning, 10, "#/properties/address", "$ref"},
+		{SeverityError, 14, "#/properties/a", "type"},
+		{SeverityError, 17, "#/properties/b", "$id"},
+	}
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %d: %v", len(expected), len(diagnostics), diagnostics)
+	}
+	for i, e := range expected {
+		d := diagnostics[i]
+		if d.Severity != e.severity || d.Line != e.line || d.Pointer != e.pointer || d.Keyword != e.keyword {
+			t.Errorf("expected %v at line %d of %s %s, got %v", e.severity, e.line, e.pointer, e.keyword, d)
+		}
+	}
+
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.Keyword != "type" {
+		t.Errorf("expected the first error to be the type, got %v", err)
+	}
+}
+
+func TestThatDuplicateIDsAreReportedAtTheLaterSchema(t *testing.T) {
+	doc := `{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "$defs": {
+        "z": { "$id": "https://example.com/item", "type": "string" },
+        "y": { "$id": "https://example.com/other", "type": "string" }
+    },
+    "properties": {
+        "b": { "$id": "https://example.com/other", "type": "integer" },
+        "a": { "$id": "https://example.com/item", "type": "integer" }
+    }
+}`
+	// map iteration would pick a different schema from time to time
+	for i := 0; i < 20; i++ {
+		_, diagnostics, err := ParseWithOptions(doc, &url.URL{Scheme: "file", Path: "/ids.json"}, ParseOptions{})
+		if err == nil {
+			t.Fatal("expected the duplicate $id to fail parsing")
+		}
+		var pointers []string
+		for _, d := range diagnostics {
+			pointers = append(pointers, d.Pointer+" "+d.Keyword)
+		}
+		if strings.Join(pointers, ", ") != "#/properties/b $id, #/properties/a $id" {
+			t.Fatalf("expected the duplicates in the properties, got %v", diagnostics)
+		}
+	}
+}
+
+func TestThatWarningsFailInStrictMode(t *testing.T) {
+	doc := `{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "type": "object",
+    "properties": {
+        "name": {
+            "$schema": "http://json-schema.org/draft-07/schema#",
+            "type": "string"
+        }
+    }
+}`
+	uri := &url.URL{Scheme: "file", Path: "/strict.json"}
+
+	root, diagnostics, err := ParseWithOptions(doc, uri, ParseOptions{})
+	if err != nil || root == nil {
+		t.Fatalf("expected warnings not to fail, got %v", err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Severity != SeverityWarning {
+		t.Errorf("expected a warning, got %v", diagnostics)
+	}
+
+	if _, _, err := ParseWithOptions(doc, uri, ParseOptions{Strict: true}); err == nil {
+		t.Error("expected the warning to fail in strict mode")
+	}
+}
+
+func TestThatEmbeddedResourcesMayDeclareTheirSchema(t *testing.T) {
+	doc := `{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "$defs": {
+        "Address": {
+            "$id": "https://example.com/address",
+            "$schema": "https://json-schema.org/draft/2020-12/schema",
+            "type": "object"
+        }
+    },
+    "$ref": "#/$defs/Address"
+}`
+	_, diagnostics, err := ParseWithOptions(doc, &url.URL{Scheme: "file", Path: "/embedded.json"}, ParseOptions{Strict: true})
+	if err != nil || len(diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diagnostics)
+	}
+}
+
+func TestThatPointersFindTheItemsOfTuples(t *testing.T) {
+	doc := `{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "type": "array",
+    "prefixItems": [{ "type": "string" }],
+    "items": { "type": "object", "properties": { "a": { "type": "integer" } } }
+}`
+	root, err := Parse(doc, &url.URL{Scheme: "file", Path: "/tuple.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rest := (*Schema)(root.AdditionalItems)
+	for pointer, expected := range map[string]*Schema{
+		"/prefixItems/0":      root.PrefixItems[0],
+		"/items":              rest,
+		"/items/properties/a": rest.Properties["a"],
+	} {
+		if s, ok := root.schemaAtPointer(pointer); !ok || s != expected {
+			t.Errorf("expected %s to point to its schema", pointer)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SchemaError is a problem of a JSON schema found while reading, resolving or generating types. Use errors.As to
+// get the details of an error returned by this package.
+type SchemaError struct {
+	// File is the path of the schema file, or the URI of the schema when it wasn't read from a file.
+	File string
+	// Line and Column locate the problem in File, counting from 1. Both are 0 when the position is unknown.
+	Line   int
+	Column int
+	// Pointer is the JSON pointer of the schema with the problem, e.g. #/properties/address.
+	Pointer string
+	// Keyword is the schema keyword with the problem, e.g. $ref.
+	Keyword string
+	// Message describes the problem.
+	Message string
+	// Err is the underlying cause, nil if there is none.
+	Err error
+}
+
+func (e *SchemaError) Error() string {
+	buf := new(strings.Builder)
+	if e.File != "" {
+		buf.WriteString(e.File)
+		if e.Line > 0 {
+			fmt.Fprintf(buf, " line %d, character %d", e.Line, e.Column)
+		}
+		buf.WriteString(": ")
+	}
+	if e.Pointer != "" {
+		buf.WriteString(e.Pointer + ": ")
+	}
+	if e.Keyword != "" {
+		buf.WriteString(e.Keyword + ": ")
+	}
+	buf.WriteString(e.Message)
+	if e.Err != nil {
+		buf.WriteString(": " + e.Err.Error())
+	}
+	return buf.String()
+}
+
+// Unwrap returns the underlying cause.
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// newSchemaError returns an error of the keyword of schema, located by the source of its root schema. The keyword
+// may be empty when the problem is the schema itself.
+func newSchemaError(schema *Schema, keyword, message string, cause error) *SchemaError {
+	root := schema.GetRoot()
+	e := &SchemaError{
+		File:    root.file,
+		Pointer: schemaPointer(schema),
+		Keyword: keyword,
+		Message: message,
+		Err:     cause,
+	}
+	if e.File == "" {
+		e.File = root.documentURI()
+	}
+	if root.document == nil || root.position == nil {
+		return e
+	}
+	// point at the keyword when it's present, at the schema otherwise
+	offset, ok := pointerOffset(root.document, e.Pointer+"/"+escapeJSONPointer(keyword))
+	if keyword == "" || !ok {
+		offset, ok = pointerOffset(root.document, e.Pointer)
+	}
+	if ok {
+		if line, column, err := root.position(offset); err == nil {
+			e.Line, e.Column = line, column
+		}
+	}
+	return e
+}
+
+// schemaPointer returns the JSON pointer of schema within its document.
+func schemaPointer(schema *Schema) string {
+	if schema.IsRoot() {
+		return "#"
+	}
+	return getPath(schema.Parent, schema.PathElement)
+}
+
+// filePath returns the path of a file URI, or the URI when it's not a file.
+func filePath(uri *url.URL) string {
+	if uri.Scheme == "file" {
+		return uri.Path
+	}
+	return uri.String()
+}
+
+// pointerOffset returns the offset of the value at the JSON pointer fragment, e.g. #/properties/name, in document.
+func pointerOffset(document []byte, pointer string) (int, bool) {
+	pointer = strings.TrimPrefix(pointer, "#")
+	dec := json.NewDecoder(bytes.NewReader(document))
+	var segments []string
+	if pointer != "" {
+		segments = strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	}
+	for _, segment := range segments {
+		segment = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+		tok, err := dec.Token()
+		if err != nil {
+			return 0, false
+		}
+		switch tok {
+		case json.Delim('{'):
+			found := false
+			for dec.More() && !found {
+				key, err := dec.Token()
+				if err != nil {
+					return 0, false
+				}
+				if key == segment {
+					found = true
+					break
+				}
+				var skip json.RawMessage
+				if err := dec.Decode(&skip); err != nil {
+					return 0, false
+				}
+			}
+			if !found {
+				return 0, false
+			}
+		case json.Delim('['):
+			index, err := strconv.Atoi(segment)
+			if err != nil {
+				return 0, false
+			}
+			for i := 0; i < index; i++ {
+				var skip json.RawMessage
+				if !dec.More() || dec.Decode(&skip) != nil {
+					return 0, false
+				}
+			}
+			if !dec.More() {
+				return 0, false
+			}
+		default:
+			return 0, false
+		}
+	}
+	// the value starts after the whitespace, colon or comma following the last token
+	offset := int(dec.InputOffset())
+	for offset < len(document) && bytes.IndexByte([]byte(" \t\r\n:,"), document[offset]) >= 0 {
+		offset++
+	}
+	return offset, offset < len(document)
+}
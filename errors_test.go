@@ -0,0 +1,171 @@
+package generate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestThatSyntaxErrorsAreSchemaErrors(t *testing.T) {
+	_, err := Parse("{\n  \"$schema\": \"x\",\n  \"title\" \"x\"\n}", &url.URL{Scheme: "file", Path: "/syntax.json"})
+
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected a SchemaError, got %v", err)
+	}
+	if schemaErr.File != "/syntax.json" || schemaErr.Line != 3 || schemaErr.Column != 11 {
+		t.Errorf("expected the error at /syntax.json line 3, character 11, got %s line %d, character %d", schemaErr.File, schemaErr.Line, schemaErr.Column)
+	}
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("expected the JSON syntax error to be wrapped, got %v", schemaErr.Err)
+	}
+}
+
+func TestThatMissingReferencesAreSchemaErrors(t *testing.T) {
+	doc := `{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "title": "Order",
+    "type": "object",
+    "properties": {
+        "customer": {
+            "$ref": "#/$defs/Customer"
+        }
+    }
+}`
+	root, err := Parse(doc, &url.URL{Scheme: "file", Path: "/order.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = New(root).CreateTypes("", "main", false)
+
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected a SchemaError, got %v", err)
+	}
+	expected := SchemaError{
+		File:    "/order.json",
+		Line:    7,
+		Column:  21,
+		Pointer: "#/properties/customer",
+		Keyword: "$ref",
+		Message: `reference "#/$defs/Customer" not found`,
+	}
+	if *schemaErr != expected {
+		t.Errorf("expected %+v, got %+v", expected, *schemaErr)
+	}
+}
+
+func TestThatHelperConflictsAreSchemaErrors(t *testing.T) {
+	doc := `{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "title": "Event",
+    "type": "object",
+    "properties": {
+        "ttl": { "type": "string", "format": "duration" },
+        "window": { "$ref": "#/$defs/Duration" }
+    },
+    "$defs": {
+        "Duration": {
+            "type": "object",
+            "properties": { "from": { "type": "string" } }
+        }
+    }
+}`
+	root, err := Parse(doc, &url.URL{Scheme: "file", Path: "/event.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = New(root).CreateTypes("", "main", false)
+
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected a SchemaError, got %v", err)
+	}
+	if schemaErr.Pointer != "#/$defs/Duration" || schemaErr.Line != 10 {
+		t.Errorf("expected the conflict at #/$defs/Duration line 10, got %s line %d", schemaErr.Pointer, schemaErr.Line)
+	}
+}
+
+func TestThatPointersOfSchemaErrorsAreEscaped(t *testing.T) {
+	doc := `{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "properties": {
+        "a/b~c": { "type": "strin" }
+    },
+    "$defs": {
+        "d/e": { "$ref": "#/$defs/missing" }
+    }
+}`
+	_, diagnostics, err := ParseWithOptions(doc, &url.URL{Scheme: "file", Path: "/escaped.json"}, ParseOptions{})
+	if err == nil {
+		t.Fatal("expected the unknown type to fail parsing")
+	}
+	expected := []struct {
+		line    int
+		pointer string
+	}{
+		{4, "#/properties/a~1b~0c"},
+		{7, "#/$defs/d~1e"},
+	}
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %v", len(expected), diagnostics)
+	}
+	for i, e := range expected {
+		if d := diagnostics[i]; d.Line != e.line || d.Pointer != e.pointer {
+			t.Errorf("expected a diagnostic at line %d of %s, got %v", e.line, e.pointer, d)
+		}
+	}
+}
+
+func TestThatYAMLSchemaErrorsHaveTheirPosition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order.yaml")
+	doc := "$schema: https://json-schema.org/draft/2020-12/schema\ntitle: Order\ntype: object\npatternProperties:\n  \"^(?!x)\":\n    type: string\n"
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	schemas, err := ReadInputFiles([]AnalysisFile{{Root: true, Path: path}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = New(schemas...).CreateTypes("", "main", false)
+
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected a SchemaError, got %v", err)
+	}
+	if schemaErr.File != path || schemaErr.Line != 5 || schemaErr.Keyword != "patternProperties" || schemaErr.Err == nil {
+		t.Errorf("expected the patternProperties error at %s line 5 with its cause, got %+v", path, *schemaErr)
+	}
+}
+
+func TestPointerOffset(t *testing.T) {
+	doc := []byte(`{"a": {"b~c": [1, {"d/e": true}]}, "f": 2}`)
+	tests := []struct {
+		pointer  string
+		expected string
+	}{
+		{pointer: "#", expected: `{"a"`},
+		{pointer: "#/f", expected: "2}"},
+		{pointer: "#/a/b~0c/1", expected: `{"d/e"`},
+		{pointer: "#/a/b~0c/1/d~1e", expected: "true"},
+	}
+	for _, test := range tests {
+		offset, ok := pointerOffset(doc, test.pointer)
+		if !ok {
+			t.Errorf("%s: expected to find the value", test.pointer)
+			continue
+		}
+		if actual := string(doc[offset : offset+len(test.expected)]); actual != test.expected {
+			t.Errorf("%s: expected the value %q, got %q", test.pointer, test.expected, actual)
+		}
+	}
+	for _, pointer := range []string{"#/x", "#/a/b~0c/2", "#/f/g"} {
+		if _, ok := pointerOffset(doc, pointer); ok {
+			t.Errorf("%s: expected no value", pointer)
+		}
+	}
+}
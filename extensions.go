@@ -0,0 +1,100 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+// The vendor extension keywords influencing the generated Go code, like the oapi-codegen ones.
+const (
+	// xGoType is an existing Go type used instead of generating one, e.g. "uuid.UUID".
+	xGoType = "x-go-type"
+	// xGoTypeImport is the package of the x-go-type, either the import path or {"path": ..., "name": ...}.
+	xGoTypeImport = "x-go-type-import"
+	// xGoName overrides the name of a field or of a type.
+	xGoName = "x-go-name"
+	// xGoTag adds struct tags to a field, e.g. `db:"name"`.
+	xGoTag = "x-go-tag"
+	// xOmitempty sets whether the tags of a field have omitempty.
+	xOmitempty = "x-omitempty"
+)
+
+// goTypeImport is the value of x-go-type-import.
+type goTypeImport struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+// extensionString returns the value of a string extension, "" when it's missing or not a string.
+func (schema *Schema) extensionString(keyword string) string {
+	var s string
+	if err := json.Unmarshal(schema.Extensions[keyword], &s); err != nil {
+		return ""
+	}
+	return s
+}
+
+// extensionBool returns the value of a boolean extension, nil when it's missing or not a boolean.
+func (schema *Schema) extensionBool(keyword string) *bool {
+	var b bool
+	if err := json.Unmarshal(schema.Extensions[keyword], &b); err != nil {
+		return nil
+	}
+	return &b
+}
+
+// goTypeImport returns the package of the x-go-type, the path is empty when there's none.
+func (schema *Schema) goTypeImport() goTypeImport {
+	raw := schema.Extensions[xGoTypeImport]
+	var imp goTypeImport
+	if err := json.Unmarshal(raw, &imp.Path); err == nil {
+		return imp
+	}
+	if err := json.Unmarshal(raw, &imp); err != nil {
+		return goTypeImport{}
+	}
+	return imp
+}
+
+// diagnoseExtensions returns the problems of the Go extensions of schema.
+func (schema *Schema) diagnoseExtensions() Diagnostics {
+	var d Diagnostics
+	add := func(severity Severity, keyword, message string) {
+		d = append(d, Diagnostic{Severity: severity, SchemaError: newSchemaError(schema, keyword, message, nil)})
+	}
+	for _, keyword := range []string{xGoType, xGoName, xGoTag} {
+		if _, ok := schema.Extensions[keyword]; ok && schema.extensionString(keyword) == "" {
+			add(SeverityError, keyword, "expected a non-empty string")
+		}
+	}
+	if name := schema.extensionString(xGoName); name != "" && !token.IsIdentifier(name) {
+		add(SeverityError, xGoName, fmt.Sprintf("%q is not a Go identifier", name))
+	}
+	if _, ok := schema.Extensions[xOmitempty]; ok && schema.extensionBool(xOmitempty) == nil {
+		add(SeverityError, xOmitempty, "expected a boolean")
+	}
+	if _, ok := schema.Extensions[xGoTypeImport]; ok {
+		if schema.goTypeImport().Path == "" {
+			add(SeverityError, xGoTypeImport, `expected an import path or {"path": ..., "name": ...}`)
+		} else if schema.extensionString(xGoType) == "" {
+			add(SeverityWarning, xGoTypeImport, "ignored without x-go-type")
+		}
+	}
+	return d
+}
+
+// goTypeOf returns the Go type of a schema with x-go-type, a pointer unless required. Types that can be nil already
+// aren't pointers.
+func goTypeOf(typ string, requires bool) string {
+	for _, prefix := range []string{"*", "[]", "map[", "interface", "any", "func", "chan"} {
+		if strings.HasPrefix(typ, prefix) {
+			return typ
+		}
+	}
+	if requires {
+		return typ
+	}
+	return "*" + typ
+}
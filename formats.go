@@ -0,0 +1,250 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Format is the Go type generated for the values of a JSON schema format.
+type Format struct {
+	// Type is the Go type, e.g. "time.Time" or "uuid.UUID".
+	Type string `json:"type"`
+	// Imports are the packages needed by the type and by the helper.
+	Imports []string `json:"imports,omitempty"`
+	// SchemaTypes are the JSON types the format applies to, all when empty. Formats of other types are ignored, so
+	// that e.g. an OpenAPI "int64" string stays a string.
+	SchemaTypes []string `json:"schemaTypes,omitempty"`
+	// Helper is the Go code declaring Type when it isn't an existing type, e.g. with its MarshalJSON and
+	// UnmarshalJSON methods. It's output once when the format is used.
+	Helper string `json:"helper,omitempty"`
+}
+
+// appliesTo reports whether the format changes the Go type of values of the JSON type schemaType.
+func (f Format) appliesTo(schemaType string) bool {
+	return len(f.SchemaTypes) == 0 || contains(f.SchemaTypes, schemaType)
+}
+
+// Formats maps the names of the JSON schema formats to their Go types. Values with a format that isn't in it are
+// generated from their type only.
+type Formats map[string]Format
+
+// DefaultFormats returns the built-in formats.
+func DefaultFormats() Formats {
+	return Formats{
+		"date-time": {Type: "time.Time", Imports: []string{"time"}, SchemaTypes: []string{"string"}},
+		"date":      {Type: "Date", Imports: []string{"encoding/json", "time"}, SchemaTypes: []string{"string"}, Helper: dateHelper},
+		"time":      {Type: "Time", Imports: []string{"encoding/json", "time"}, SchemaTypes: []string{"string"}, Helper: timeHelper},
+		"duration": {
+			Type:        "Duration",
+			Imports:     []string{"encoding/json", "fmt", "regexp", "strconv", "strings", "time"},
+			SchemaTypes: []string{"string"},
+			Helper:      durationHelper,
+		},
+		"uri":   {Type: "URI", Imports: []string{"encoding/json", "fmt", "net/url"}, SchemaTypes: []string{"string"}, Helper: uriHelper},
+		"uuid":  {Type: "UUID", SchemaTypes: []string{"string"}, Helper: uuidHelper},
+		"email": {Type: "Email", SchemaTypes: []string{"string"}, Helper: emailHelper},
+		"ipv4":  {Type: "netip.Addr", Imports: []string{"net/netip"}, SchemaTypes: []string{"string"}},
+		"ipv6":  {Type: "netip.Addr", Imports: []string{"net/netip"}, SchemaTypes: []string{"string"}},
+		// encoding/json reads a []byte from base64, which is what "byte" is. "binary" is raw octets, e.g. of a
+		// multipart body, so it stays a string.
+		"byte":   {Type: "[]byte", SchemaTypes: []string{"string"}},
+		"int32":  {Type: "int32", SchemaTypes: []string{"integer"}},
+		"int64":  {Type: "int64", SchemaTypes: []string{"integer"}},
+		"float":  {Type: "float32", SchemaTypes: []string{"number"}},
+		"double": {Type: "float64", SchemaTypes: []string{"number"}},
+		// json.Number keeps every digit of the value
+		"decimal": {Type: "json.Number", Imports: []string{"encoding/json"}, SchemaTypes: []string{"number"}},
+	}
+}
+
+// LoadFormats returns the built-in formats changed by the JSON file at path, an object of formats keyed by their
+// name. A null format removes a built-in one, e.g. {"uuid": {"type": "uuid.UUID", "imports":
+// ["github.com/google/uuid"]}, "date": null}.
+func LoadFormats(path string) (Formats, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the formats %s: %w", path, err)
+	}
+	var config map[string]*Format
+	if err := json.Unmarshal(b, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse the formats %s: %w", path, err)
+	}
+	formats := DefaultFormats()
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := config[name]
+		if f == nil {
+			delete(formats, name)
+			continue
+		}
+		if f.Type == "" {
+			return nil, fmt.Errorf("the format %q of %s has no type", name, path)
+		}
+		formats[name] = *f
+	}
+	return formats, nil
+}
+
+// formatOf returns the format of the values of schema with the JSON type schemaType, also when it's one of the
+// types of a oneOf.
+func formatOf(schema *Schema, schemaType string) string {
+	if format, ok := schema.FormatValue.(string); ok {
+		return format
+	}
+	for _, s := range schema.OneOf {
+		if s.TypeValue == schemaType {
+			if format, ok := s.FormatValue.(string); ok {
+				return format
+			}
+		}
+	}
+	return ""
+}
+
+const dateHelper = `// Date is a calendar date read and written as an RFC 3339 full-date, e.g. "2006-01-02".
+type Date struct {
+	time.Time
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format(time.DateOnly))
+}
+
+func (d *Date) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return err
+	}
+	d.Time = t
+	return nil
+}
+`
+
+const timeHelper = `// Time is a time of day read and written as an RFC 3339 full-time, e.g. "15:04:05Z" or "15:04:05.5+02:00".
+type Time struct {
+	time.Time
+}
+
+const timeLayout = "15:04:05.999999999Z07:00"
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Format(timeLayout))
+}
+
+func (t *Time) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.Parse(timeLayout, s)
+	if err != nil {
+		return err
+	}
+	t.Time = v
+	return nil
+}
+`
+
+const uriHelper = `// URI is an absolute URI, e.g. "https://example.com/a?b=c".
+type URI struct {
+	url.URL
+}
+
+func (u URI) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.String())
+}
+
+func (u *URI) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if !v.IsAbs() {
+		return fmt.Errorf("the URI %q isn't absolute", s)
+	}
+	u.URL = *v
+	return nil
+}
+`
+
+const uuidHelper = `// UUID is a string holding an RFC 4122 UUID, e.g. "f81d4fae-7dec-11d0-a765-00a0c91e6bf6".
+type UUID = string
+`
+
+const emailHelper = `// Email is a string holding an RFC 5321 email address.
+type Email = string
+`
+
+const durationHelper = `// Duration is a time.Duration read and written as an ISO 8601 duration, e.g. "PT1H30M". Years and months don't
+// have a fixed length and are rejected.
+type Duration time.Duration
+
+var durationPattern = regexp.MustCompile("^(-)?P(?:(\\d+(?:[.,]\\d+)?)W)?(?:(\\d+(?:[.,]\\d+)?)D)?(?:T(?:(\\d+(?:[.,]\\d+)?)H)?(?:(\\d+(?:[.,]\\d+)?)M)?(?:(\\d+(?:[.,]\\d+)?)S)?)?$")
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	v := time.Duration(d)
+	s := "PT"
+	if v < 0 {
+		s = "-PT"
+		v = -v
+	}
+	if h := v / time.Hour; h > 0 {
+		s += strconv.FormatInt(int64(h), 10) + "H"
+		v -= h * time.Hour
+	}
+	if m := v / time.Minute; m > 0 {
+		s += strconv.FormatInt(int64(m), 10) + "M"
+		v -= m * time.Minute
+	}
+	if v > 0 || strings.HasSuffix(s, "T") {
+		s += strconv.FormatFloat(v.Seconds(), 'f', -1, 64) + "S"
+	}
+	return json.Marshal(s)
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	m := durationPattern.FindStringSubmatch(s)
+	if m == nil || strings.HasSuffix(s, "T") {
+		return fmt.Errorf("invalid ISO 8601 duration %q", s)
+	}
+	var total float64
+	found := false
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.Replace(m[i+2], ",", ".", 1), 64)
+		if err != nil {
+			return fmt.Errorf("invalid ISO 8601 duration %q: %w", s, err)
+		}
+		total += n * float64(unit)
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("invalid ISO 8601 duration %q", s)
+	}
+	if m[1] == "-" {
+		total = -total
+	}
+	*d = Duration(total)
+	return nil
+}
+`
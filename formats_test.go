@@ -0,0 +1,124 @@
+package generate
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestThatFormatsChooseTheGoType(t *testing.T) {
+	root, err := Parse(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "title": "Event",
+    "type": "object",
+    "properties": {
+        "at": { "type": "string", "format": "date-time" },
+        "ttl": { "type": "string", "format": "duration" },
+        "count": { "type": "integer", "format": "int64" },
+        "big": { "type": "string", "format": "int64" },
+        "blob": { "type": "string", "format": "byte" },
+        "day": { "oneOf": [{ "type": "string", "format": "date" }, { "type": "null" }] }
+    },
+    "required": ["at", "count"]
+}`, &url.URL{Scheme: "file", Path: "/event.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := New(root)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal("Failed to create structs: ", err)
+	}
+	event := g.Structs["Event"]
+
+	testField(event.Fields["At"], "at", "At", "time.Time", true, t)
+	testField(event.Fields["Ttl"], "ttl", "Ttl", "*Duration", false, t)
+	testField(event.Fields["Count"], "count", "Count", "int64", true, t)
+	testField(event.Fields["Big"], "big", "Big", "*string", false, t)
+	testField(event.Fields["Blob"], "blob", "Blob", "[]byte", false, t)
+	testField(event.Fields["Day"], "day", "Day", "*Date", false, t)
+
+	if _, ok := g.Imports["time"]; !ok {
+		t.Errorf("Expected the time import, got %v", g.Imports)
+	}
+	if _, ok := g.Helpers["Duration"]; !ok {
+		t.Errorf("Expected the Duration helper, got %v", g.Helpers)
+	}
+
+	var buf bytes.Buffer
+	Output(&buf, g, "main", false, false)
+	if !strings.Contains(buf.String(), "type Duration time.Duration") {
+		t.Errorf("Expected the Duration helper to be output, got:\n%s", buf.String())
+	}
+}
+
+func TestThatFormatsCanBeConfigured(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "formats.json")
+	config := `{"uuid": {"type": "uuid.UUID", "imports": ["github.com/google/uuid"]}, "date": null}`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	formats, err := LoadFormats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if formats["uuid"].Type != "uuid.UUID" {
+		t.Errorf("Expected the uuid format, got %+v", formats["uuid"])
+	}
+	if _, ok := formats["date"]; ok {
+		t.Error("Expected the date format to be removed")
+	}
+	if formats["date-time"].Type != "time.Time" {
+		t.Errorf("Expected the built-in formats to be kept, got %+v", formats["date-time"])
+	}
+
+	if err := os.WriteFile(path, []byte(`{"uuid": {}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFormats(path); err == nil {
+		t.Error("Expected an error for a format without a type")
+	}
+}
+
+func TestThatFormatHelpersRoundTrip(t *testing.T) {
+	doc := `{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "title": "Event",
+    "type": "object",
+    "properties": {
+        "at": { "type": "string", "format": "time" },
+        "link": { "type": "string", "format": "uri" },
+        "id": { "type": "string", "format": "uuid" },
+        "email": { "type": "string", "format": "email" },
+        "upload": { "type": "string", "format": "binary" }
+    },
+    "required": ["at", "link", "id", "email", "upload"]
+}`
+	main := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var e Event
+	in := ` + "`" + `{"at":"10:20:30.5+02:00","email":"a@example.com","id":"f81d4fae-7dec-11d0-a765-00a0c91e6bf6","link":"https://example.com/a?b=c","upload":"raw"}` + "`" + `
+	if err := json.Unmarshal([]byte(in), &e); err != nil {
+		panic(err)
+	}
+	out, err := json.Marshal(e)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(out) == in, e.At.Hour(), e.Link.Host)
+	fmt.Println(json.Unmarshal([]byte(` + "`" + `{"link":"relative/path"}` + "`" + `), &e) != nil)
+}
+`
+	if out := runGenerated(t, doc, main); out != "true 10 example.com\ntrue\n" {
+		t.Errorf("expected the values to round trip, got %q", out)
+	}
+}
@@ -2,8 +2,13 @@ package generate
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode"
@@ -15,9 +20,41 @@ type Generator struct {
 	resolver *RefResolver
 	Structs  map[string]Struct
 	Aliases  map[string]Field
+	// Imports are the packages of the x-go-type types and of the formats; k=import path v=package name, "" for the
+	// default
+	Imports map[string]string
+	// Formats are the Go types of the JSON schema formats, DefaultFormats unless changed before CreateTypes.
+	Formats Formats
+	// Helpers declare the types of the formats used and Nullable; k=Go type v=code
+	Helpers map[string]string
+	// GenericNullable generates the values that can be null as a Nullable[T], which tells absent and null values
+	// apart, instead of a pointer.
+	GenericNullable bool
+	// Diagnostics are the warnings of CreateTypes, e.g. an "if" schema the generated Validate methods can't test.
+	Diagnostics Diagnostics
+	// Loader resolves the references to other documents, e.g. a remote $id mapped to a local mirror by a MapLoader.
+	// It's a FileLoader of the root path when nil.
+	Loader Loader
+	// Packages are the Go packages of the types of the documents whose URI or file path starts with a prefix, the
+	// longest one; k=prefix v=import path. The types of the other documents are in the package of CreateTypes.
+	Packages map[string]string
 	// cache for reference types; k=url v=type
 	refs      map[string]string
 	anonCount int
+	// positions of the schemas in the documents, numbered depth first in the order of the documents
+	positions map[*Schema]int
+	// the schemas of the types of Structs, to locate their errors; k=type name
+	typeSchemas map[string]*Schema
+	// the schemas being processed, outermost first, to detect reference cycles
+	stack []*pendingType
+}
+
+// pendingType is a schema whose type is being generated.
+type pendingType struct {
+	schema *Schema
+	name   string
+	// recursive is set when the schema refers to itself through arrays or maps, its type is then defined with name
+	recursive bool
 }
 
 // New creates an instance of a generator which will produce structs.
@@ -27,15 +64,27 @@ func New(schemas ...*Schema) *Generator {
 		resolver: NewRefResolver(schemas),
 		Structs:  make(map[string]Struct),
 		Aliases:  make(map[string]Field),
+		Imports:  make(map[string]string),
+		Formats:  DefaultFormats(),
+		Helpers:  make(map[string]string),
 		refs:     make(map[string]string),
 	}
 }
 
 // CreateTypes creates types from the JSON schemas, keyed by the golang name.
 func (g *Generator) CreateTypes(rootPath, pkg string, bson bool) (err error) {
+	g.resolver.Loader = g.Loader
+	if g.resolver.Loader == nil {
+		g.resolver.Loader = FileLoader{RootPath: rootPath}
+	}
 	if err := g.resolver.Init(); err != nil {
 		return err
 	}
+	g.positions = make(map[*Schema]int)
+	g.typeSchemas = make(map[string]*Schema)
+	for _, schema := range g.schemas {
+		g.numberSchemas(schema)
+	}
 
 	// extract the types
 	for _, schema := range g.schemas {
@@ -56,14 +105,93 @@ func (g *Generator) CreateTypes(rootPath, pkg string, bson bool) (err error) {
 		//	g.Aliases[a.Name] = a
 		//}
 	}
-	return
+	for name := range g.Helpers {
+		if _, ok := g.Structs[name]; ok {
+			return g.typeError(name, "", fmt.Sprintf("the helper type %s conflicts with a generated type, rename it with x-go-name", name))
+		}
+	}
+	g.diagnoseConditions()
+	return g.checkPackages()
+}
+
+// diagnoseConditions warns about the conditions whose required properties the Validate methods can't check.
+func (g *Generator) diagnoseConditions() {
+	for _, name := range getOrderedStructNames(g.Structs) {
+		s := g.Structs[name]
+		vw := &validationWriter{s: s, structs: g.Structs, imports: make(map[string]bool)}
+		for _, c := range s.Conditions {
+			if vw.requiredChecks("", c.Then) == "" && vw.requiredChecks("", c.Else) == "" {
+				continue
+			}
+			if _, ok := vw.conditionExpr(c); ok {
+				continue
+			}
+			message := fmt.Sprintf("the condition %s is not supported, its required properties are not checked", c.Path)
+			err := &SchemaError{Pointer: c.Path, Keyword: "if", Message: message}
+			if schema := g.conditionSchema(name, c); schema != nil {
+				err = newSchemaError(schema, "if", message, nil)
+			}
+			g.Diagnostics = append(g.Diagnostics, Diagnostic{Severity: SeverityWarning, SchemaError: err})
+		}
+	}
+}
+
+// conditionSchema returns the schema holding the "if" schema of c, a condition of the type name, nil when it's unknown.
+func (g *Generator) conditionSchema(name string, c Condition) *Schema {
+	schema, ok := g.typeSchemas[name]
+	if !ok {
+		return nil
+	}
+	for _, conditional := range append([]*Schema{schema}, schema.AllOf...) {
+		if conditional.If != nil && g.resolver.GetPath(conditional.If) == c.Path {
+			return conditional
+		}
+	}
+	return schema
 }
 
-// process a block of $defs
+// numberSchemas records the position of schema and of its sub-schemas in the document.
+func (g *Generator) numberSchemas(schema *Schema) {
+	if _, ok := g.positions[schema]; ok {
+		return
+	}
+	g.positions[schema] = len(g.positions)
+	for _, sub := range schema.subSchemas() {
+		g.numberSchemas(sub)
+	}
+}
+
+// typeError returns an error of the type name, located by its schema when it's known.
+func (g *Generator) typeError(name, keyword, message string) error {
+	if schema, ok := g.typeSchemas[name]; ok {
+		return newSchemaError(schema, keyword, message, nil)
+	}
+	return &SchemaError{Keyword: keyword, Message: message}
+}
+
+// position returns the position of schema in the documents, schemas that aren't part of them come last.
+func (g *Generator) position(schema *Schema) int {
+	if p, ok := g.positions[schema]; ok {
+		return p
+	}
+	return math.MaxInt
+}
+
+// process a block of $defs, definitions or OpenAPI components
 func (g *Generator) processDefinitions(rootPath, pkg string, schema *Schema) error {
-	for key, subSchema := range schema.Definitions {
-		if _, err := g.processSchema(rootPath, pkg, getGolangName(key), false, false, subSchema); err != nil {
-			return err
+	for keyword, defs := range schema.definitionsByKeyword() {
+		for _, key := range schema.definitionKeys(keyword) {
+			if defs[key].GeneratedType != "" || g.pendingType(defs[key]) != nil {
+				// generated already for a reference, or being generated
+				continue
+			}
+			name := getGolangName(key)
+			if goName := defs[key].extensionString(xGoName); goName != "" {
+				name = goName
+			}
+			if _, err := g.processSchema(rootPath, pkg, name, false, false, defs[key]); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -71,15 +199,17 @@ func (g *Generator) processDefinitions(rootPath, pkg string, schema *Schema) err
 
 // process a reference string
 func (g *Generator) processReference(rootPath, pkg string, schema *Schema, requires bool) (string, error) {
-	schemaPath := g.resolver.GetPath(schema)
-	if schema.Reference == "" {
-		return "", errors.New("processReference empty reference: " + schemaPath)
-	}
-	refSchema, err := g.resolver.GetSchemaByReference(rootPath, schema)
+	refSchema, err := g.referencedSchema(rootPath, schema)
 	if err != nil {
-		return "", errors.New("processReference: reference \"" + schema.Reference + "\" not found at \"" + schemaPath + "\"")
+		return "", err
 	}
 	if refSchema.GeneratedType == "" {
+		// the schema refers to itself through arrays or maps only, its type is named so that it can refer to itself
+		if frame := g.pendingType(refSchema); frame != nil && !g.isNamedCycle(frame) {
+			frame.recursive = true
+			refSchema.GeneratedType = frame.name
+			return frame.name, nil
+		}
 		// reference is not resolved yet. Do that now.
 		refSchemaName := g.getSchemaName("", refSchema)
 		typeName, err := g.processSchema(rootPath, pkg, refSchemaName, false, requires, refSchema)
@@ -88,20 +218,244 @@ func (g *Generator) processReference(rootPath, pkg string, schema *Schema, requi
 		}
 		return typeName, nil
 	}
+	// singleton types always have their value, interfaces and defined arrays and maps are nil already
+	if s, ok := g.Structs[refSchema.GeneratedType]; ok && s.ConstType != "" {
+		return refSchema.GeneratedType, nil
+	}
+	if strings.HasSuffix(refSchema.GeneratedType, "Interface") || g.isDefinedType(refSchema.GeneratedType) {
+		return refSchema.GeneratedType, nil
+	}
 	if !requires {
 		return "*" + refSchema.GeneratedType, nil
 	}
 	return refSchema.GeneratedType, nil
 }
 
+// referencedSchema returns the schema referenced by schema, the last one of a reference to a reference, unless they
+// form a cycle without any type.
+func (g *Generator) referencedSchema(rootPath string, schema *Schema) (*Schema, error) {
+	if schema.Reference == "" {
+		return nil, newSchemaError(schema, "$ref", "empty reference", nil)
+	}
+	refSchema, err := g.resolver.GetSchemaByReference(rootPath, schema)
+	if err != nil {
+		var schemaErr *SchemaError
+		if errors.As(err, &schemaErr) {
+			return nil, err
+		}
+		return nil, newSchemaError(schema, "$ref", fmt.Sprintf("reference %q not found", schema.Reference), err)
+	}
+	chain := []*Schema{schema}
+	for refSchema.isReferenceOnly() {
+		for i, s := range chain {
+			if s == refSchema {
+				return nil, newSchemaError(schema, "$ref", "reference cycle "+g.cyclePath(append(chain[i:], refSchema)), nil)
+			}
+		}
+		chain = append(chain, refSchema)
+		if refSchema, err = g.resolver.GetSchemaByReference(rootPath, refSchema); err != nil {
+			return nil, err
+		}
+	}
+	return refSchema, nil
+}
+
+// derivedSchema returns the object of schema extending the object it references, see Schema.extendsReference: the
+// properties and the required ones of both, the other keywords of schema taking precedence. It returns nil when the
+// referenced schema isn't an object.
+func (g *Generator) derivedSchema(rootPath string, schema *Schema, extending map[*Schema]bool) (*Schema, error) {
+	if extending[schema] {
+		return nil, newSchemaError(schema, "$ref", fmt.Sprintf("%q extends itself", schema.Reference), nil)
+	}
+	extending[schema] = true
+	base, err := g.referencedSchema(rootPath, schema)
+	if err != nil {
+		return nil, err
+	}
+	if base.extendsReference() {
+		if base, err = g.derivedSchema(rootPath, base, extending); err != nil || base == nil {
+			return nil, err
+		}
+	}
+	base.FixMissingTypeValue()
+	if types, isMultiType, _ := base.MultiType(); isMultiType || len(types) != 1 || types[0] != "object" {
+		return nil, nil
+	}
+
+	derived := &Schema{
+		SchemaType:        schema.SchemaType,
+		ID04:              schema.ID04,
+		ID06:              schema.ID06,
+		Title:             schema.Title,
+		Description:       schema.Description,
+		Root:              schema.Root,
+		TypeValue:         "object",
+		Deprecated:        schema.Deprecated,
+		Properties:        make(map[string]*Schema, len(base.Properties)+len(schema.Properties)),
+		Required:          append(append([]string(nil), base.Required...), schema.Required...),
+		PatternProperties: make(map[string]*Schema, len(base.PatternProperties)+len(schema.PatternProperties)),
+		If:                base.If,
+		Then:              base.Then,
+		Else:              base.Else,
+		AllOf:             append(append([]*Schema(nil), base.AllOf...), schema.AllOf...),
+		Parent:            schema.Parent,
+		JSONKey:           schema.JSONKey,
+		PathElement:       schema.PathElement,
+		Extensions:        schema.Extensions,
+		keyOrder:          map[string][]string{"properties": append(base.PropertyKeys(), schema.PropertyKeys()...)},
+		uri:               schema.uri,
+	}
+	for _, from := range []*Schema{base, schema} {
+		for k, prop := range from.Properties {
+			derived.Properties[k] = prop
+		}
+		for pattern, prop := range from.PatternProperties {
+			derived.PatternProperties[pattern] = prop
+		}
+		if from.AdditionalProperties != nil {
+			derived.AdditionalProperties = from.AdditionalProperties
+		}
+		if from.PropertyNames != nil {
+			derived.PropertyNames = from.PropertyNames
+		}
+	}
+	if schema.If != nil {
+		derived.If, derived.Then, derived.Else = schema.If, schema.Then, schema.Else
+	}
+	if derived.Description == "" {
+		derived.Description = base.Description
+	}
+	g.positions[derived] = g.position(schema)
+	return derived, nil
+}
+
 // returns the type refered to by schema after resolving all dependencies
 func (g *Generator) processSchema(rootPath, pkg string, schemaName string, bson, requires bool, schema *Schema) (typ string, err error) {
-	if len(schema.Definitions) > 0 {
+	if schema.hasDefinitions() {
 		err := g.processDefinitions(rootPath, pkg, schema)
 		if err != nil {
 			return "", err
 		}
 	}
+	if !schema.isNullable() {
+		return g.processType(rootPath, pkg, schemaName, bson, requires, schema)
+	}
+	// nullable values are pointers, or a Nullable telling null and absent apart
+	if !g.GenericNullable {
+		return g.processType(rootPath, pkg, schemaName, bson, false, schema)
+	}
+	typ, err = g.processType(rootPath, pkg, schemaName, bson, true, schema)
+	if err != nil || typ == "interface{}" || g.Structs[typ].Func.Name != "" {
+		// interfaces are null already
+		return typ, err
+	}
+	g.Imports["encoding/json"] = ""
+	g.Helpers["Nullable"] = nullableHelper
+	return "Nullable[" + typ + "]", nil
+}
+
+// returns the type of the values of schema other than null
+func (g *Generator) processType(rootPath, pkg string, schemaName string, bson, requires bool, schema *Schema) (string, error) {
+	frame := &pendingType{schema: schema, name: schemaName}
+	g.stack = append(g.stack, frame)
+	typ, err := g.processValueType(rootPath, pkg, schemaName, bson, requires, schema)
+	g.stack = g.stack[:len(g.stack)-1]
+	if err != nil || !frame.recursive {
+		return typ, err
+	}
+	// the array or map refers to itself by name
+	g.typeSchemas[frame.name] = schema
+	g.Structs[frame.name] = Struct{
+		ID:          schema.ID(),
+		SourceOrder: g.position(schema),
+		Package:     g.packageOf(schema),
+		Name:        frame.name,
+		Description: schema.Description,
+		Type:        typ,
+	}
+	return frame.name, nil
+}
+
+// pendingType returns the type being generated for schema, nil when there's none.
+func (g *Generator) pendingType(schema *Schema) *pendingType {
+	for _, frame := range g.stack {
+		if frame.schema == schema {
+			return frame
+		}
+	}
+	return nil
+}
+
+// isNamedCycle returns true when a struct or an interface is being generated since frame, the cycle back to frame
+// goes through its name.
+func (g *Generator) isNamedCycle(frame *pendingType) bool {
+	for i := len(g.stack) - 1; g.stack[i] != frame; i-- {
+		if g.stack[i].schema.GeneratedType != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// isDefinedType returns true when typ is a recursive array or map, which is defined with a name.
+func (g *Generator) isDefinedType(typ string) bool {
+	if g.Structs[typ].Type != "" {
+		return true
+	}
+	for _, frame := range g.stack {
+		if frame.recursive && frame.name == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// byReference returns typ as a pointer when it's a struct being generated, which can't contain itself.
+func (g *Generator) byReference(typ string) string {
+	for _, frame := range g.stack {
+		if frame.schema.GeneratedType == typ && !frame.recursive && !strings.HasSuffix(typ, "Interface") {
+			return "*" + typ
+		}
+	}
+	return typ
+}
+
+// cyclePath returns the locations of the schemas of a reference cycle, e.g. "#/$defs/a -> #/$defs/b -> #/$defs/a".
+// Schemas of other documents than the first one are prefixed by their file.
+func (g *Generator) cyclePath(cycle []*Schema) string {
+	locations := make([]string, len(cycle))
+	for i, s := range cycle {
+		locations[i] = g.resolver.GetPath(s)
+		if root := s.GetRoot(); root != cycle[0].GetRoot() {
+			locations[i] = filepath.Base(documentPath(root.documentURI())) + locations[i]
+		}
+	}
+	return strings.Join(locations, " -> ")
+}
+
+// returns the type of the values of schema other than null, once it's on the stack
+func (g *Generator) processValueType(rootPath, pkg string, schemaName string, bson, requires bool, schema *Schema) (typ string, err error) {
+	// an existing type instead of a generated one
+	if goType := schema.extensionString(xGoType); goType != "" {
+		if imp := schema.goTypeImport(); imp.Path != "" {
+			g.Imports[imp.Path] = imp.Name
+		}
+		return goTypeOf(goType, requires), nil
+	}
+	// the properties next to a reference extend the referenced object
+	if schema.extendsReference() {
+		derived, err := g.derivedSchema(rootPath, schema, make(map[*Schema]bool))
+		if err != nil {
+			return "", err
+		}
+		if derived != nil {
+			if !derived.isAdditionalPropertiesMap() {
+				// the references to schema are to the derived type
+				schema.GeneratedType = schemaName
+			}
+			return g.processObject(rootPath, pkg, schemaName, bson, requires, derived)
+		}
+	}
 	schema.FixMissingTypeValue()
 	// if we have multiple schema types, the golang type will be interface{}
 	typ = "interface{}"
@@ -122,7 +476,7 @@ func (g *Generator) processSchema(rootPath, pkg string, schemaName string, bson,
 					return rv, nil
 				}
 			case "array":
-				rv, err := g.processArray(rootPath, pkg, name, schema)
+				rv, err := g.processArray(rootPath, pkg, name, requires, schema)
 				if err != nil {
 					return "", err
 				}
@@ -132,7 +486,10 @@ func (g *Generator) processSchema(rootPath, pkg string, schemaName string, bson,
 			default:
 				rv, err := getPrimitiveTypeName(schemaType, "", !requires)
 				if err != nil {
-					return "", err
+					return "", newSchemaError(schema, "type", "unsupported type", err)
+				}
+				if f, ok := g.Formats[formatOf(schema, schemaType)]; ok && f.appliesTo(schemaType) {
+					rv = g.processFormat(f, requires)
 				}
 				if !isMultiType {
 					return rv, nil
@@ -140,10 +497,13 @@ func (g *Generator) processSchema(rootPath, pkg string, schemaName string, bson,
 			}
 		}
 	} else {
+		if schema.ConstValue != nil {
+			return g.processConst(schemaName, schema)
+		}
 		if schema.Reference != "" {
 			return g.processReference(rootPath, pkg, schema, requires)
 		}
-		if len(schema.OneOf) > 0 {
+		if len(schema.OneOf) > 0 || (len(schema.AnyOf) > 0 && schema.Discriminator != nil) {
 			return g.processInterface(rootPath, pkg, schemaName, requires, schema)
 		}
 		if len(schema.EnumValue) > 0 {
@@ -153,9 +513,25 @@ func (g *Generator) processSchema(rootPath, pkg string, schemaName string, bson,
 	return // return interface{}
 }
 
+// processFormat returns the Go type of a value with the format f and records its imports and helper.
+func (g *Generator) processFormat(f Format, requires bool) string {
+	for _, path := range f.Imports {
+		if _, ok := g.Imports[path]; !ok {
+			g.Imports[path] = ""
+		}
+	}
+	if f.Helper != "" {
+		g.Helpers[f.Type] = f.Helper
+	}
+	return goTypeOf(f.Type, requires)
+}
+
 // name: name of this array, usually the js key
 // schema: items element
-func (g *Generator) processArray(rootPath, pkg string, name string, schema *Schema) (typeStr string, err error) {
+func (g *Generator) processArray(rootPath, pkg string, name string, requires bool, schema *Schema) (typeStr string, err error) {
+	if len(schema.PrefixItems) > 0 {
+		return g.processTuple(rootPath, pkg, name, requires, schema)
+	}
 	if schema.Items != nil {
 		// subType: fallback name in case this array contains inline object without a title
 		subName := g.getSchemaName(name+"Items", schema.Items)
@@ -163,13 +539,15 @@ func (g *Generator) processArray(rootPath, pkg string, name string, schema *Sche
 		if err != nil {
 			return "", err
 		}
+		// interfaces, maps, slices and defined arrays and maps are nil already
 		pointer := true
-		if strings.HasSuffix(subTyp, "Interface") {
+		if strings.HasSuffix(subTyp, "Interface") || g.isDefinedType(subTyp) ||
+			strings.HasPrefix(subTyp, "map[") || strings.HasPrefix(subTyp, "[]") {
 			pointer = false
 		}
 		finalType, err := getPrimitiveTypeName("array", subTyp, pointer)
 		if err != nil {
-			return "", err
+			return "", newSchemaError(schema, "items", "unsupported items", err)
 		}
 		// only alias root arrays
 		if schema.Parent == nil {
@@ -187,46 +565,262 @@ func (g *Generator) processArray(rootPath, pkg string, name string, schema *Sche
 	return "[]interface{}", nil
 }
 
+// name: name of the tuple struct (calculated by caller)
+// schema: array with positional prefixItems
+// returns: generated type, marshalled as a JSON array
+func (g *Generator) processTuple(rootPath, pkg string, name string, requires bool, schema *Schema) (typ string, err error) {
+	strct := Struct{
+		ID:          schema.ID(),
+		SourceOrder: g.position(schema),
+		Package:     g.packageOf(schema),
+		Name:        name,
+		Description: schema.Description,
+		Fields:      make(map[string]Field, len(schema.PrefixItems)+1),
+	}
+	// cache the tuple name in case any sub-schemas recursively reference it
+	schema.GeneratedType = name
+	for i, item := range schema.PrefixItems {
+		// positions are named after their title, e.g. [lat, lon], falling back to the index
+		fieldName := "Item" + strconv.Itoa(i)
+		if item.Title != "" {
+			if _, exists := strct.Fields[getGolangName(item.Title)]; !exists {
+				fieldName = getGolangName(item.Title)
+			}
+		}
+		subSchemaName := g.getSchemaName(name+fieldName, item)
+		fieldType, err := g.processSchema(rootPath, pkg, subSchemaName, false, true, item)
+		if err != nil {
+			return "", err
+		}
+		fieldType = g.byReference(fieldType)
+		f := Field{
+			Name:        fieldName,
+			JSONName:    strconv.Itoa(i),
+			Type:        fieldType,
+			Required:    true,
+			Description: fieldDescription(item),
+			Constraints: g.getConstraints(rootPath, item),
+			SourceOrder: g.position(item),
+		}
+		strct.Fields[f.Name] = f
+		strct.TupleFields = append(strct.TupleFields, f.Name)
+	}
+	if ai := schema.AdditionalItems; ai != nil && ai.AdditionalPropertiesBool != nil && !*ai.AdditionalPropertiesBool {
+		// no items allowed after the tuple positions
+		strct.TupleAdditionalType = "false"
+	} else {
+		subTyp := "interface{}"
+		if ai != nil && ai.AdditionalPropertiesBool == nil {
+			aiSchema := (*Schema)(ai)
+			subTyp, err = g.processSchema(rootPath, pkg, g.getSchemaName(name+"AdditionalItems", aiSchema), false, true, aiSchema)
+			if err != nil {
+				return "", err
+			}
+		}
+		f := Field{
+			Name:        "AdditionalItems",
+			JSONName:    "-",
+			Type:        "[]" + subTyp,
+			Required:    false,
+			SourceOrder: math.MaxInt,
+		}
+		if ai != nil {
+			f.SourceOrder = g.position((*Schema)(ai))
+		}
+		strct.Fields[f.Name] = f
+		strct.TupleAdditionalType = subTyp
+	}
+	// setting this will cause marshal code to be emitted in Output()
+	strct.GenerateCode = true
+
+	g.Structs[strct.Name] = strct
+	g.typeSchemas[strct.Name] = schema
+
+	// tuples are pointers like objects
+	typ, err = getPrimitiveTypeName("object", name, !requires)
+	if err != nil {
+		return "", newSchemaError(schema, "", "unsupported tuple", err)
+	}
+	return typ, nil
+}
+
+// strct: the struct of the object, the properties of "then" and "else" are added as optional fields
+// schema: object with "if" conditions, directly or in "allOf"
+func (g *Generator) processConditions(rootPath, pkg string, strct *Struct, schema *Schema) error {
+	conditionals := []*Schema{schema}
+	conditionals = append(conditionals, schema.AllOf...)
+	for _, conditional := range conditionals {
+		if conditional.If == nil || (conditional.Then == nil && conditional.Else == nil) {
+			continue
+		}
+		tests, supported := conditionTests(conditional.If)
+		condition := Condition{
+			Path:        g.resolver.GetPath(conditional.If),
+			If:          tests,
+			Unsupported: !supported,
+		}
+		for _, branch := range []*Schema{conditional.Then, conditional.Else} {
+			if branch == nil {
+				continue
+			}
+			for _, propKey := range branch.PropertyKeys() {
+				fieldName := getFieldName(propKey, branch.Properties[propKey])
+				if _, exists := strct.Fields[fieldName]; exists {
+					continue
+				}
+				prop := branch.Properties[propKey]
+				fieldType, err := g.processSchema(rootPath, pkg, g.getSchemaName(fieldName, prop), false, false, prop)
+				if err != nil {
+					return err
+				}
+				// the constraints only apply in the branch, they are not checked
+				f := Field{
+					Name:        fieldName,
+					JSONName:    propKey,
+					Type:        fieldType,
+					Required:    false,
+					Description: fieldDescription(prop),
+					SourceOrder: g.position(prop),
+					Tags:        prop.extensionString(xGoTag),
+					Omitempty:   prop.extensionBool(xOmitempty),
+				}
+				strct.Fields[f.Name] = f
+			}
+		}
+		if conditional.Then != nil {
+			condition.Then = conditional.Then.Required
+		}
+		if conditional.Else != nil {
+			condition.Else = conditional.Else.Required
+		}
+		strct.Conditions = append(strct.Conditions, condition)
+	}
+	return nil
+}
+
+// conditionTests returns the property tests of an "if" schema. It's not supported unless it only has properties
+// with "const" or "enum" values and "required" properties.
+func conditionTests(schema *Schema) (tests []ConditionTest, supported bool) {
+	if schema.Reference != "" || len(schema.AllOf) > 0 || len(schema.AnyOf) > 0 || len(schema.OneOf) > 0 ||
+		schema.If != nil || len(schema.PatternProperties) > 0 {
+		return nil, false
+	}
+	keys := make([]string, 0, len(schema.Properties))
+	for propKey := range schema.Properties {
+		keys = append(keys, propKey)
+	}
+	sort.Strings(keys)
+	for _, propKey := range keys {
+		prop := schema.Properties[propKey]
+		test := ConditionTest{Property: propKey, Required: contains(schema.Required, propKey)}
+		switch {
+		case prop.ConstValue != nil:
+			var value any
+			if err := json.Unmarshal(prop.ConstValue, &value); err != nil {
+				return nil, false
+			}
+			test.Values = []any{value}
+		case len(prop.EnumValue) > 0:
+			test.Values = prop.EnumValue
+		default:
+			return nil, false
+		}
+		for _, v := range test.Values {
+			switch v.(type) {
+			case string, bool, float64:
+			default:
+				return nil, false
+			}
+		}
+		tests = append(tests, test)
+	}
+	for _, propKey := range schema.Required {
+		if _, tested := schema.Properties[propKey]; !tested {
+			tests = append(tests, ConditionTest{Property: propKey, Required: true})
+		}
+	}
+	return tests, true
+}
+
+// name: name of the object (calculated by caller)
+// schema: object with propertyNames
+// returns: golang type of the property names, a string enum or "string"
+func (g *Generator) processPropertyNames(rootPath, pkg string, name string, schema *Schema) (typ string, err error) {
+	names := schema.PropertyNames
+	if names == nil {
+		return "string", nil
+	}
+	switch {
+	case len(names.EnumValue) > 0:
+		typ, err = g.processEnum(g.getSchemaName(name+"Key", names), names, true)
+	case names.Reference != "":
+		typ, err = g.processReference(rootPath, pkg, names, true)
+	default:
+		return "string", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if s, ok := g.Structs[typ]; ok && s.EnumType == "string" {
+		return typ, nil
+	}
+	return "string", nil
+}
+
 // name: name of the struct (calculated by caller)
 // schema: detail incl properties & child objects
 // returns: generated type
 func (g *Generator) processObject(rootPath, pkg string, name string, bson bool, requires bool, schema *Schema) (typ string, err error) {
 	strct := Struct{
 		ID:          schema.ID(),
+		SourceOrder: g.position(schema),
+		Package:     g.packageOf(schema),
 		Name:        name,
 		Description: schema.Description,
 		Fields:      make(map[string]Field, len(schema.Properties)),
 	}
-	// cache the object name in case any sub-schemas recursively reference it
-	schema.GeneratedType = name
+	// cache the object name in case any sub-schemas recursively reference it, unless it's a map of its additional
+	// properties without a struct
+	if !schema.isAdditionalPropertiesMap() {
+		schema.GeneratedType = name
+	}
 	// regular properties
 	if bson && schema.Root {
 		f := Field{
-			Name:     "ObjectId",
-			JSONName: "_id",
-			Type:     "*primitive.ObjectID",
-			Required: false,
+			Name:        "ObjectId",
+			JSONName:    "_id",
+			Type:        "*primitive.ObjectID",
+			Required:    false,
+			SourceOrder: -1,
 		}
 		strct.Fields[f.Name] = f
 	}
-	for propKey, prop := range schema.Properties {
-		fieldName := getGolangName(propKey)
+	for _, propKey := range schema.PropertyKeys() {
+		prop := schema.Properties[propKey]
+		fieldName := getFieldName(propKey, prop)
 		// calculate sub-schema name here, may not actually be used depending on type of schema!
 		subSchemaName := g.getSchemaName(fieldName, prop)
+		if prop.ConstValue != nil && prop.Title == "" {
+			// constants like "eventType" differ between objects
+			subSchemaName = name + fieldName
+		}
 		required := contains(schema.Required, propKey)
 		fieldType, err := g.processSchema(rootPath, pkg, subSchemaName, false, required, prop)
 		if err != nil {
 			return "", err
 		}
+		fieldType = g.byReference(fieldType)
 		f := Field{
 			Name:        fieldName,
 			JSONName:    propKey,
 			Type:        fieldType,
 			Required:    required,
-			Description: prop.Description,
-		}
-		if prop.Deprecated {
-			f.Description = "@deprecated: " + prop.Description
+			Description: fieldDescription(prop),
+			Constraints: g.getConstraints(rootPath, prop),
+			SourceOrder: g.position(prop),
+			Tags:        prop.extensionString(xGoTag),
+			Omitempty:   prop.extensionBool(xOmitempty),
+			Nullable:    prop.isNullable(),
 		}
 		if f.Required {
 			strct.GenerateCode = true
@@ -234,6 +828,61 @@ func (g *Generator) processObject(rootPath, pkg string, name string, bson bool,
 		strct.Fields[f.Name] = f
 
 	}
+	// if/then/else, also as allOf schemas
+	if err := g.processConditions(rootPath, pkg, &strct, schema); err != nil {
+		return "", err
+	}
+	// the keys of the maps of dynamic properties
+	keyType, err := g.processPropertyNames(rootPath, pkg, name, schema)
+	if err != nil {
+		return "", err
+	}
+	nameConstraints := g.getConstraints(rootPath, schema.PropertyNames)
+	// patternProperties, a map per regular expression
+	patterns := make([]string, 0, len(schema.PatternProperties))
+	for pattern := range schema.PatternProperties {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for i, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return "", newSchemaError(schema, "patternProperties",
+				fmt.Sprintf("%q is not supported by the Go regexp package", pattern), err)
+		}
+		prop := schema.PatternProperties[pattern]
+		fieldName, valueName := "PatternProperties", name+"Value"
+		if len(patterns) > 1 {
+			fieldName += strconv.Itoa(i + 1)
+			valueName += strconv.Itoa(i + 1)
+		}
+		subTyp, err := g.processSchema(rootPath, pkg, g.getSchemaName(valueName, prop), false, true, prop)
+		if err != nil {
+			return "", err
+		}
+		f := Field{
+			Name:        fieldName,
+			JSONName:    "-",
+			Type:        "map[" + keyType + "]" + subTyp,
+			Required:    false,
+			Description: fieldDescription(prop),
+			Constraints: mapConstraints(nameConstraints, g.getConstraints(rootPath, prop)),
+			SourceOrder: g.position(prop),
+		}
+		if f.Description == "" {
+			f.Description = "Properties matching " + pattern
+		}
+		strct.Fields[f.Name] = f
+		strct.PatternProperties = append(strct.PatternProperties, PatternProperty{
+			Pattern:   pattern,
+			Field:     f.Name,
+			ValueType: subTyp,
+		})
+		// setting this will cause marshal code to be emitted in Output()
+		strct.GenerateCode = true
+	}
+	if keyType != "string" {
+		strct.KeyType = keyType
+	}
 	// additionalProperties with typed sub-schema
 	if schema.AdditionalProperties != nil && schema.AdditionalProperties.AdditionalPropertiesBool == nil {
 		ap := (*Schema)(schema.AdditionalProperties)
@@ -242,14 +891,13 @@ func (g *Generator) processObject(rootPath, pkg string, name string, bson bool,
 		if err != nil {
 			return "", err
 		}
-		mapTyp := "map[string]" + subTyp
+		mapTyp := "map[" + keyType + "]" + subTyp
 		// If this object is inline property for another object, and only contains additional properties, we can
 		// collapse the structure down to a map.
 		//
 		// If this object is a definition and only contains additional properties, we can't do that or we end up with
 		// no struct
-		isDefinitionObject := strings.HasPrefix(schema.PathElement, "$defs")
-		if len(schema.Properties) == 0 && !isDefinitionObject {
+		if schema.isAdditionalPropertiesMap() {
 			// since there are no regular properties, we don't need to emit a struct for this object - return the
 			// additionalProperties map type.
 			return mapTyp, nil
@@ -261,6 +909,8 @@ func (g *Generator) processObject(rootPath, pkg string, name string, bson bool,
 			Type:        mapTyp,
 			Required:    false,
 			Description: "",
+			Constraints: mapConstraints(nameConstraints, g.getConstraints(rootPath, ap)),
+			SourceOrder: g.position(ap),
 		}
 		strct.Fields[f.Name] = f
 		// setting this will cause marshal code to be emitted in Output()
@@ -271,13 +921,15 @@ func (g *Generator) processObject(rootPath, pkg string, name string, bson bool,
 	if schema.AdditionalProperties != nil && schema.AdditionalProperties.AdditionalPropertiesBool != nil {
 		if *schema.AdditionalProperties.AdditionalPropertiesBool {
 			// everything is valid additional
-			subTyp := "map[string]interface{}"
+			subTyp := "map[" + keyType + "]interface{}"
 			f := Field{
 				Name:        "AdditionalProperties",
 				JSONName:    "-",
 				Type:        subTyp,
 				Required:    false,
 				Description: "",
+				Constraints: mapConstraints(nameConstraints, nil),
+				SourceOrder: g.position((*Schema)(schema.AdditionalProperties)),
 			}
 			strct.Fields[f.Name] = f
 			// setting this will cause marshal code to be emitted in Output()
@@ -294,15 +946,22 @@ func (g *Generator) processObject(rootPath, pkg string, name string, bson bool,
 	}
 
 	g.Structs[strct.Name] = strct
+	g.typeSchemas[strct.Name] = schema
 
 	// objects are always a pointer
-	return getPrimitiveTypeName("object", name, !requires)
+	typ, err = getPrimitiveTypeName("object", name, !requires)
+	if err != nil {
+		return "", newSchemaError(schema, "", "unsupported object", err)
+	}
+	return typ, nil
 }
 
 func (g *Generator) processInterface(rootPath, pkg string, name string, requires bool, schema *Schema) (typ string, err error) {
 	name = name + "Interface"
 	strct := Struct{
 		ID:          schema.ID(),
+		SourceOrder: g.position(schema),
+		Package:     g.packageOf(schema),
 		Name:        name,
 		Description: schema.Description,
 		Func: Func{
@@ -311,21 +970,158 @@ func (g *Generator) processInterface(rootPath, pkg string, name string, requires
 		},
 	}
 
-	var refer *string
-	for _, oneOf := range schema.OneOf {
-		if oneOf.Reference != "" {
-			refer = &oneOf.Reference
-			split := strings.Split(oneOf.Reference, "/")
-			strct.Func.NameTypes = append(strct.Func.NameTypes, split[len(split)-1])
+	variants := schema.OneOf
+	if len(variants) == 0 {
+		variants = schema.AnyOf
+	}
+	var refs, implementations []*Schema
+	for _, variant := range variants {
+		if variant.Reference != "" {
+			refs = append(refs, variant)
 		}
 	}
 
-	if len(strct.Func.NameTypes) == 1 {
-		schema.Reference = *refer
+	if len(refs) == 1 {
+		schema.Reference = refs[0].Reference
 		return g.processReference(rootPath, pkg, schema, requires)
 	}
+	// cache the interface name in case any implementation recursively references it
+	schema.GeneratedType = name
+
+	// implicit discriminator values are the schema names
+	implicit := make(map[string]string, len(refs))
+	for _, ref := range refs {
+		typeName, err := g.processReference(rootPath, pkg, ref, true)
+		if err != nil {
+			return "", err
+		}
+		// only generated types can implement the interface
+		if _, ok := g.Structs[typeName]; !ok {
+			continue
+		}
+		strct.Func.NameTypes = append(strct.Func.NameTypes, typeName)
+		// the discriminated implementations are decoded into a new struct
+		if g.Structs[typeName].isStruct() {
+			split := strings.Split(ref.Reference, "/")
+			implicit[split[len(split)-1]] = typeName
+		}
+		if refSchema, err := g.resolver.GetSchemaByReference(rootPath, ref); err == nil {
+			implementations = append(implementations, refSchema)
+		}
+	}
+
+	if schema.Discriminator == nil && len(implementations) == len(strct.Func.NameTypes) {
+		// a property with a different constant in every implementation discriminates them as well
+		strct.DiscriminatorProperty, strct.DiscriminatorMapping = constDiscriminator(implementations, strct.Func.NameTypes)
+	}
+
+	if schema.Discriminator != nil {
+		strct.DiscriminatorProperty = schema.Discriminator.PropertyName
+		strct.DiscriminatorMapping = make(map[string]string, len(implicit)+len(schema.Discriminator.Mapping))
+		// the explicit values replace the implicit names of the types they map
+		mapped := make(map[string]bool, len(schema.Discriminator.Mapping))
+		for value, target := range schema.Discriminator.Mapping {
+			// the target is either a reference or the name of a schema
+			if !strings.ContainsAny(target, "#/.") {
+				target = "#/" + schema.GetRoot().definitionsKeyword(target) + "/" + target
+			}
+			typeName, err := g.processReference(rootPath, pkg, &Schema{Reference: target, Parent: schema}, true)
+			if err != nil {
+				return "", err
+			}
+			if !g.Structs[typeName].isStruct() {
+				return "", newSchemaError(schema, "discriminator",
+					fmt.Sprintf("the mapping of %q is %s, which isn't an object", value, typeName), nil)
+			}
+			strct.DiscriminatorMapping[value] = typeName
+			mapped[typeName] = true
+		}
+		for value, typeName := range implicit {
+			if !mapped[typeName] {
+				strct.DiscriminatorMapping[value] = typeName
+			}
+		}
+	}
 
 	g.Structs[strct.Name] = strct
+	g.typeSchemas[strct.Name] = schema
+
+	return name, nil
+}
+
+// constDiscriminator returns the property with a different string constant in every schema, and the golang types
+// keyed by the constants. The property is empty when there's none.
+func constDiscriminator(schemas []*Schema, types []string) (property string, mapping map[string]string) {
+	if len(schemas) < 2 {
+		return "", nil
+	}
+	candidates := make([]string, 0, len(schemas[0].Properties))
+	for k := range schemas[0].Properties {
+		candidates = append(candidates, k)
+	}
+	sort.Strings(candidates)
+	for _, candidate := range candidates {
+		mapping = make(map[string]string, len(schemas))
+		for i, s := range schemas {
+			prop, ok := s.Properties[candidate]
+			if !ok {
+				break
+			}
+			value, ok := prop.constString()
+			if !ok {
+				break
+			}
+			if _, duplicate := mapping[value]; duplicate {
+				break
+			}
+			mapping[value] = types[i]
+		}
+		if len(mapping) == len(schemas) {
+			return candidate, mapping
+		}
+	}
+	return "", nil
+}
+
+// name: name of the singleton type (calculated by caller)
+// schema: schema with a "const" value
+// returns: the singleton type, never a pointer as its value is always known
+func (g *Generator) processConst(name string, schema *Schema) (typ string, err error) {
+	var value interface{}
+	if err := json.Unmarshal(schema.ConstValue, &value); err != nil {
+		return "", newSchemaError(schema, "const", "invalid const value", err)
+	}
+	strct := Struct{
+		ID:          schema.ID(),
+		SourceOrder: g.position(schema),
+		Package:     g.packageOf(schema),
+		Name:        name,
+		Description: schema.Description,
+		ConstValue:  value,
+	}
+	switch v := value.(type) {
+	case string:
+		strct.ConstType = "string"
+	case bool:
+		strct.ConstType = "bool"
+	case float64:
+		strct.ConstType = "float64"
+		if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+			strct.ConstType = "int"
+			strct.ConstValue = int(v)
+		}
+	default:
+		// objects, arrays and null are kept as JSON
+		b := new(bytes.Buffer)
+		if err := json.Compact(b, schema.ConstValue); err != nil {
+			return "", newSchemaError(schema, "const", "invalid const value", err)
+		}
+		strct.ConstType = "struct{}"
+		strct.ConstValue = b.String()
+	}
+	schema.GeneratedType = name
+	g.Structs[strct.Name] = strct
+	g.typeSchemas[strct.Name] = schema
 
 	return name, nil
 }
@@ -333,6 +1129,8 @@ func (g *Generator) processInterface(rootPath, pkg string, name string, requires
 func (g *Generator) processEnum(name string, schema *Schema, requires bool) (typ string, err error) {
 	strct := Struct{
 		ID:          schema.ID(),
+		SourceOrder: g.position(schema),
+		Package:     g.packageOf(schema),
 		Name:        name,
 		Description: schema.Description,
 	}
@@ -394,6 +1192,7 @@ func (g *Generator) processEnum(name string, schema *Schema, requires bool) (typ
 	}
 
 	g.Structs[strct.Name] = strct
+	g.typeSchemas[strct.Name] = schema
 
 	if !requires {
 		return "*" + name, nil
@@ -449,7 +1248,8 @@ func getPrimitiveTypeName(schemaType string, subType string, pointer bool) (name
 		}
 		return "float64", nil
 	case "null":
-		return "nil", nil
+		// only nil is valid
+		return "interface{}", nil
 	case "object":
 		if subType == "" {
 			return "error_creating_object", errors.New("can't create an object of an empty subtype")
@@ -463,11 +1263,6 @@ func getPrimitiveTypeName(schemaType string, subType string, pointer bool) (name
 			return "*string", nil
 		}
 		return "string", nil
-	case "time":
-		if pointer {
-			return "*time.Time", nil
-		}
-		return "time.Time", nil
 	}
 
 	return "undefined", fmt.Errorf("failed to get a primitive type for schemaType %s and subtype %s",
@@ -476,6 +1271,9 @@ func getPrimitiveTypeName(schemaType string, subType string, pointer bool) (name
 
 // return a name for this (sub-)schema.
 func (g *Generator) getSchemaName(keyName string, schema *Schema) string {
+	if name := schema.extensionString(xGoName); name != "" {
+		return name
+	}
 	if len(schema.Title) > 0 {
 		return getGolangName(schema.Title)
 	}
@@ -495,6 +1293,39 @@ func (g *Generator) getSchemaName(keyName string, schema *Schema) string {
 	return fmt.Sprintf("Anonymous%d", g.anonCount)
 }
 
+// fieldDescription returns the doc comment of a field with the schema prop: its description followed by its default
+// value and whether it's read-only or write-only, marked when it's deprecated.
+func fieldDescription(prop *Schema) string {
+	var lines []string
+	if prop.Description != "" {
+		lines = append(lines, prop.Description)
+	}
+	if prop.Default != nil {
+		if b, err := json.Marshal(prop.Default); err == nil {
+			lines = append(lines, "Default: "+string(b))
+		}
+	}
+	if prop.ReadOnly {
+		lines = append(lines, "Read-only: set by the owner of the resource.")
+	}
+	if prop.WriteOnly {
+		lines = append(lines, "Write-only: not sent back by the owner of the resource.")
+	}
+	description := strings.Join(lines, "\n")
+	if prop.Deprecated {
+		return "@deprecated: " + description
+	}
+	return description
+}
+
+// getFieldName returns the golang name of the property propKey, x-go-name when set.
+func getFieldName(propKey string, prop *Schema) string {
+	if name := prop.extensionString(xGoName); name != "" {
+		return name
+	}
+	return getGolangName(propKey)
+}
+
 // getGolangName strips invalid characters out of golang struct or field names.
 func getGolangName(s string) string {
 	buf := bytes.NewBuffer([]byte{})
@@ -547,6 +1378,11 @@ type Struct struct {
 	ID string
 	// The golang name, e.g. "Address"
 	Name string
+	// Package is the import path of the package of the type, see Generator.Packages, "" for the package of
+	// CreateTypes.
+	Package string
+	// SourceOrder is the position of the schema of the type in the documents.
+	SourceOrder int
 	// Description of the struct
 	Description string
 	Fields      map[string]Field
@@ -556,8 +1392,74 @@ type Struct struct {
 	Enums    []Enum
 	EnumType string
 
+	// ConstType is the golang type underlying a singleton type of a "const" value, "struct{}" for objects, arrays
+	// and null. ConstValue is the golang value, or the JSON encoding for "struct{}".
+	ConstType  string
+	ConstValue any
+
 	GenerateCode   bool
 	AdditionalType string
+
+	// PatternProperties are the maps of the properties matching a regular expression, in the order the expressions
+	// are tried.
+	PatternProperties []PatternProperty
+	// KeyType is the golang type of the keys of the property maps when set by "propertyNames", "" for string.
+	KeyType string
+
+	// Conditions are the if/then/else schemas of the object.
+	Conditions []Condition
+
+	// DiscriminatorProperty names the property selecting the implementation of an interface, the mapping is keyed
+	// by the property value with the golang type as value.
+	DiscriminatorProperty string
+	DiscriminatorMapping  map[string]string
+
+	// TupleFields lists the fields of a tuple in the order of the JSON array positions.
+	TupleFields []string
+	// TupleAdditionalType is the golang type of the items following the tuple positions, "false" if none are
+	// allowed.
+	TupleAdditionalType string
+
+	// Type is the golang type of an array or map referring to itself, e.g. "[]Tree" or "map[string]Tree", which is
+	// defined with Name.
+	Type string
+}
+
+// isStruct returns true when s is generated as a struct, rather than as an enum, a constant, an interface or an array
+// or map type. The zero Struct of an unknown type isn't one.
+func (s Struct) isStruct() bool {
+	return s.Name != "" && len(s.Enums) == 0 && s.EnumType == "" && s.ConstType == "" && s.Func.Name == "" && s.Type == ""
+}
+
+// Condition is an "if" schema with the properties required by "then" and "else".
+type Condition struct {
+	// Path of the "if" schema, e.g. #/allOf/0/if
+	Path string
+	// If are the tests of the properties, the condition holds when all pass.
+	If []ConditionTest
+	// Then and Else are the JSON names of the properties required when the condition holds or not.
+	Then []string
+	Else []string
+	// Unsupported is true when the "if" schema can't be tested by the generated code.
+	Unsupported bool
+}
+
+// ConditionTest tests the value of a property.
+type ConditionTest struct {
+	// Property is the JSON name of the property.
+	Property string
+	// Values are the valid values, a string, bool or float64. Any value is valid when empty.
+	Values []any
+	// Required is true when the property has to be present, an absent property passes the test otherwise.
+	Required bool
+}
+
+// PatternProperty is a map of the properties with a name matching Pattern.
+type PatternProperty struct {
+	Pattern string
+	// Field is the golang name of the map field
+	Field     string
+	ValueType string
 }
 
 type Func struct {
@@ -582,4 +1484,15 @@ type Field struct {
 	// Required is set to true when the field is required.
 	Required    bool
 	Description string
+	// Constraints are the validation keywords of the field, nil if there are none.
+	Constraints *Constraints
+	// SourceOrder is the position of the schema of the field in the documents, -1 for fields added by the generator
+	// ahead of the properties.
+	SourceOrder int
+	// Tags are the struct tags added by x-go-tag, e.g. `db:"name"`.
+	Tags string
+	// Omitempty is set by x-omitempty to add or remove omitempty from the tags, nil for the default.
+	Omitempty *bool
+	// Nullable is set to true when the value can be null.
+	Nullable bool
 }
@@ -2,6 +2,8 @@ package generate
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
@@ -73,7 +75,7 @@ func TestFieldGeneration(t *testing.T) {
 	}
 	root.Init()
 	g := New(&root)
-	err := g.CreateTypes("main", false)
+	err := g.CreateTypes("", "main", false)
 
 	//Output(os.Stderr, g, "test")
 
@@ -86,11 +88,11 @@ func TestFieldGeneration(t *testing.T) {
 	}
 
 	testField(g.Structs["TestFieldGeneration"].Fields["Property1"], "property1", "Property1", "*string", false, t)
-	testField(g.Structs["TestFieldGeneration"].Fields["Property2"], "property2", "Property2", "*Address", true, t)
+	testField(g.Structs["TestFieldGeneration"].Fields["Property2"], "property2", "Property2", "Address", true, t)
 	testField(g.Structs["TestFieldGeneration"].Fields["Property3"], "property3", "Property3", "*SubObj1", false, t)
 	testField(g.Structs["TestFieldGeneration"].Fields["Property4"], "property4", "Property4", "map[string]int", false, t)
 	testField(g.Structs["TestFieldGeneration"].Fields["Property5"], "property5", "Property5", "*SubObj3", false, t)
-	testField(g.Structs["TestFieldGeneration"].Fields["Property6"], "property6", "Property6", "map[string]*SubObj4a", false, t)
+	testField(g.Structs["TestFieldGeneration"].Fields["Property6"], "property6", "Property6", "map[string]SubObj4a", false, t)
 	testField(g.Structs["TestFieldGeneration"].Fields["Property7"], "property7", "Property7", "map[string]interface{}", false, t)
 	testField(g.Structs["TestFieldGeneration"].Fields["Property8"], "property8", "Property8", "*SubObj5", false, t)
 
@@ -102,6 +104,37 @@ func TestFieldGeneration(t *testing.T) {
 	testField(g.Structs["SubObj5"].Fields["AdditionalProperties"], "-", "AdditionalProperties", "map[string]int", false, t)
 }
 
+func TestLegacyDefinitionGeneration(t *testing.T) {
+	root := &Schema{
+		SchemaType: "http://json-schema.org/draft-04/schema#",
+		Title:      "Customer",
+		TypeValue:  "object",
+		Properties: map[string]*Schema{
+			"billing":  {Reference: "#/definitions/address"},
+			"location": {Reference: "#/$defs/point"},
+		},
+		LegacyDefinitions: map[string]*Schema{
+			"address": {TypeValue: "object", Properties: map[string]*Schema{"street": {TypeValue: "string"}}},
+		},
+		Definitions: map[string]*Schema{
+			"point": {TypeValue: "object", Properties: map[string]*Schema{"lat": {TypeValue: "number"}}},
+		},
+		Required: []string{"billing"},
+	}
+	root.Init()
+
+	g := New(root)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal("Failed to create structs: ", err)
+	}
+
+	if _, ok := g.Structs["Address"]; !ok {
+		t.Errorf("The Address type should have been made, but only types %s were made.", strings.Join(getStructNamesFromMap(g.Structs), ", "))
+	}
+	testField(g.Structs["Customer"].Fields["Billing"], "billing", "Billing", "Address", true, t)
+	testField(g.Structs["Customer"].Fields["Location"], "location", "Location", "*Point", false, t)
+}
+
 func TestFieldGenerationWithArrayReferences(t *testing.T) {
 	properties := map[string]*Schema{
 		"property1": {TypeValue: "string"},
@@ -143,7 +176,7 @@ func TestFieldGenerationWithArrayReferences(t *testing.T) {
 	root.Init()
 
 	g := New(&root)
-	err := g.CreateTypes("main", false)
+	err := g.CreateTypes("", "main", false)
 
 	//Output(os.Stderr, g, "test")
 
@@ -191,7 +224,7 @@ func TestNestedStructGeneration(t *testing.T) {
 	root.Init()
 
 	g := New(root)
-	err := g.CreateTypes("main", false)
+	err := g.CreateTypes("", "main", false)
 	results := g.Structs
 
 	//Output(os.Stderr, g, "test")
@@ -232,7 +265,7 @@ func TestEmptyNestedStructGeneration(t *testing.T) {
 	root.Init()
 
 	g := New(root)
-	err := g.CreateTypes("main", false)
+	err := g.CreateTypes("", "main", false)
 	results := g.Structs
 
 	// Output(os.Stderr, g, "test")
@@ -305,7 +338,7 @@ func TestStructGeneration(t *testing.T) {
 	root.Init()
 
 	g := New(root)
-	err := g.CreateTypes("main", false)
+	err := g.CreateTypes("", "main", false)
 	results := g.Structs
 
 	// Output(os.Stderr, g, "test")
@@ -336,7 +369,7 @@ func TestArrayGeneration(t *testing.T) {
 	root.Init()
 
 	g := New(root)
-	err := g.CreateTypes("main", false)
+	err := g.CreateTypes("", "main", false)
 	results := g.Structs
 
 	// Output(os.Stderr, g, "test")
@@ -367,6 +400,63 @@ func TestArrayGeneration(t *testing.T) {
 	}
 }
 
+func TestTupleGeneration(t *testing.T) {
+	closed := false
+	root := &Schema{
+		Title:     "Event",
+		TypeValue: "object",
+		Properties: map[string]*Schema{
+			"location": {
+				Title:     "Point",
+				TypeValue: "array",
+				PrefixItems: []*Schema{
+					{Title: "lat", TypeValue: "number"},
+					{Title: "lon", TypeValue: "number"},
+				},
+				AdditionalItems: &AdditionalProperties{AdditionalPropertiesBool: &closed},
+			},
+			"pair": {
+				TypeValue: "array",
+				PrefixItems: []*Schema{
+					{TypeValue: "string"},
+					{TypeValue: "integer"},
+				},
+				AdditionalItems: &AdditionalProperties{TypeValue: "boolean"},
+			},
+		},
+		Required: []string{"location"},
+	}
+
+	root.Init()
+
+	g := New(root)
+	err := g.CreateTypes("", "main", false)
+	results := g.Structs
+
+	if err != nil {
+		t.Fatal("Failed to create structs: ", err)
+	}
+
+	testField(results["Event"].Fields["Location"], "location", "Location", "Point", true, t)
+	testField(results["Event"].Fields["Pair"], "pair", "Pair", "*Pair", false, t)
+
+	point := results["Point"]
+	if !reflect.DeepEqual(point.TupleFields, []string{"Lat", "Lon"}) {
+		t.Errorf("Expected the Point tuple fields to be Lat and Lon, but got %v", point.TupleFields)
+	}
+	if point.TupleAdditionalType != "false" {
+		t.Errorf("Expected no additional items to be allowed for Point, but got %q", point.TupleAdditionalType)
+	}
+	testField(point.Fields["Lat"], "0", "Lat", "float64", true, t)
+
+	pair := results["Pair"]
+	if !reflect.DeepEqual(pair.TupleFields, []string{"Item0", "Item1"}) {
+		t.Errorf("Expected the Pair tuple fields to be Item0 and Item1, but got %v", pair.TupleFields)
+	}
+	testField(pair.Fields["Item1"], "1", "Item1", "int", true, t)
+	testField(pair.Fields["AdditionalItems"], "-", "AdditionalItems", "[]bool", false, t)
+}
+
 func TestNestedArrayGeneration(t *testing.T) {
 	root := &Schema{
 		Title:     "Favourite Bars",
@@ -394,7 +484,7 @@ func TestNestedArrayGeneration(t *testing.T) {
 	root.Init()
 
 	g := New(root)
-	err := g.CreateTypes("main", false)
+	err := g.CreateTypes("", "main", false)
 	results := g.Structs
 
 	//Output(os.Stderr, g, "test", false)
@@ -429,8 +519,8 @@ func TestNestedArrayGeneration(t *testing.T) {
 		t.Errorf("Expected to find the Tags field on the FavouriteBars, but didn't. The struct is %+v", fbStruct)
 	}
 
-	if f.Type != "[]string" {
-		t.Errorf("Expected to find that the Tags array was of type string, but it was of %s", f.Type)
+	if f.Type != "[]*string" {
+		t.Errorf("Expected to find that the Tags array was of type *string, but it was of %s", f.Type)
 	}
 
 	cityStruct, ok := results["City"]
@@ -476,7 +566,7 @@ func TestMultipleSchemaStructGeneration(t *testing.T) {
 	root2.Init()
 
 	g := New(root1, root2)
-	err := g.CreateTypes("main", false)
+	err := g.CreateTypes("", "main", false)
 	results := g.Structs
 
 	// Output(os.Stderr, g, "test")
@@ -490,6 +580,41 @@ func TestMultipleSchemaStructGeneration(t *testing.T) {
 	}
 }
 
+func TestThatDefinitionsReferencedFromAnotherDocumentAreGeneratedOnce(t *testing.T) {
+	root1 := &Schema{
+		Title: "Order",
+		ID06:  "http://example.com/schema/order",
+		Properties: map[string]*Schema{
+			"address": {Reference: "common#/$defs/a~1b"},
+		},
+	}
+
+	root2 := &Schema{
+		ID06: "http://example.com/schema/common",
+		Definitions: map[string]*Schema{
+			"a/b": {
+				Title: "Address",
+				Properties: map[string]*Schema{
+					"city": {TypeValue: "string"},
+				},
+			},
+		},
+	}
+
+	root1.Init()
+	root2.Init()
+
+	g := New(root1, root2)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal("Failed to create structs: ", err)
+	}
+
+	if len(g.Structs) != 2 {
+		t.Errorf("2 results should have been created, the order and its address, but got %v", getStructNamesFromMap(g.Structs))
+	}
+	testField(g.Structs["Order"].Fields["Address"], "address", "Address", "*Address", false, t)
+}
+
 func TestThatJavascriptKeyNamesCanBeConvertedToValidGoNames(t *testing.T) {
 	tests := []struct {
 		description string
@@ -560,7 +685,7 @@ func TestThatArraysWithoutDefinedItemTypesAreGeneratedAsEmptyInterfaces(t *testi
 	root.Init()
 
 	g := New(root)
-	err := g.CreateTypes("main", false)
+	err := g.CreateTypes("", "main", false)
 	results := g.Structs
 
 	// Output(os.Stderr, g, "test")
@@ -594,7 +719,7 @@ func TestThatTypesWithMultipleDefinitionsAreGeneratedAsEmptyInterfaces(t *testin
 	root.Init()
 
 	g := New(root)
-	err := g.CreateTypes("main", false)
+	err := g.CreateTypes("", "main", false)
 	results := g.Structs
 
 	// Output(os.Stderr, g, "test")
@@ -676,29 +801,28 @@ func TestThatUnmarshallingIsPossible(t *testing.T) {
 	}
 }
 
-func TestTypeAliases(t *testing.T) {
+// TestRootTypes checks the types of root schemas other than objects, which aren't declared: only the structs they
+// refer to are.
+func TestRootTypes(t *testing.T) {
 	tests := []struct {
-		gotype           string
-		input            *Schema
-		structs, aliases int
+		gotype  string
+		input   *Schema
+		structs int
 	}{
 		{
 			gotype:  "*string",
 			input:   &Schema{TypeValue: "string"},
 			structs: 0,
-			aliases: 1,
 		},
 		{
 			gotype:  "*int",
 			input:   &Schema{TypeValue: "integer"},
 			structs: 0,
-			aliases: 1,
 		},
 		{
 			gotype:  "*bool",
 			input:   &Schema{TypeValue: "boolean"},
 			structs: 0,
-			aliases: 1,
 		},
 		{
 			gotype: "[]*Foo",
@@ -711,13 +835,11 @@ func TestTypeAliases(t *testing.T) {
 					},
 				}},
 			structs: 1,
-			aliases: 1,
 		},
 		{
 			gotype:  "[]interface{}",
 			input:   &Schema{TypeValue: "array"},
 			structs: 0,
-			aliases: 1,
 		},
 		{
 			gotype: "map[string]string",
@@ -726,7 +848,6 @@ func TestTypeAliases(t *testing.T) {
 				AdditionalProperties: (*AdditionalProperties)(&Schema{TypeValue: "string"}),
 			},
 			structs: 0,
-			aliases: 1,
 		},
 		{
 			gotype: "map[string]interface{}",
@@ -735,7 +856,6 @@ func TestTypeAliases(t *testing.T) {
 				AdditionalProperties: (*AdditionalProperties)(&Schema{TypeValue: []interface{}{"string", "integer"}}),
 			},
 			structs: 0,
-			aliases: 1,
 		},
 	}
 
@@ -743,31 +863,707 @@ func TestTypeAliases(t *testing.T) {
 		test.input.Init()
 
 		g := New(test.input)
-		err := g.CreateTypes("main", false)
-		structs := g.Structs
-		aliases := g.Aliases
-
-		// Output(os.Stderr, g, "test")
+		if err := g.CreateTypes("", "main", false); err != nil {
+			t.Fatal(err)
+		}
+		if len(g.Structs) != test.structs {
+			t.Errorf("Expected %d structs, got %d", test.structs, len(g.Structs))
+		}
 
+		typ, err := g.processSchema("", "main", "Root", false, false, test.input)
 		if err != nil {
 			t.Fatal(err)
 		}
+		if typ != test.gotype {
+			t.Errorf("Expected Root type %q, got %q", test.gotype, typ)
+		}
+	}
+}
+
+// Root is an example of a generated type.
+type Root struct {
+	Name interface{} `json:"name,omitempty"`
+}
+
+func TestOpenAPIComponentGeneration(t *testing.T) {
+	doc := `{
+        "openapi": "3.0.3",
+        "info": { "title": "Pets", "version": "1" },
+        "paths": {},
+        "components": {
+            "schemas": {
+                "Pet": {
+                    "oneOf": [
+                        { "$ref": "#/components/schemas/Cat" },
+                        { "$ref": "#/components/schemas/Dog" },
+                        { "$ref": "#/components/schemas/Bird" }
+                    ],
+                    "discriminator": {
+                        "propertyName": "petType",
+                        "mapping": { "kitty": "#/components/schemas/Cat", "doggo": "Dog" }
+                    }
+                },
+                "Cat": {
+                    "type": "object",
+                    "required": [ "petType", "name" ],
+                    "properties": {
+                        "petType": { "type": "string" },
+                        "name": { "type": "string", "nullable": true }
+                    }
+                },
+                "Dog": {
+                    "type": "object",
+                    "properties": {
+                        "petType": { "type": "string" }
+                    }
+                },
+                "Bird": {
+                    "type": "object",
+                    "properties": {
+                        "petType": { "type": "string" }
+                    }
+                }
+            }
+        }
+    }`
+	root, err := Parse(doc, &url.URL{Scheme: "file", Path: "/openapi.json"})
+	if err != nil {
+		t.Fatal("It should be possible to parse an OpenAPI document without a $schema key:", err)
+	}
+	if root.Draft() != Draft04 {
+		t.Errorf("Expected OpenAPI 3.0 schemas to be a draft-04 dialect, but got %d", root.Draft())
+	}
+
+	g := New(root)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal("Failed to create structs: ", err)
+	}
+
+	testField(g.Structs["Cat"].Fields["PetType"], "petType", "PetType", "string", true, t)
+	testField(g.Structs["Cat"].Fields["Name"], "name", "Name", "*string", true, t)
+
+	pet, ok := g.Structs["PetInterface"]
+	if !ok {
+		t.Fatalf("The PetInterface type should have been made, but only types %s were made.", strings.Join(getStructNamesFromMap(g.Structs), ", "))
+	}
+	if pet.DiscriminatorProperty != "petType" {
+		t.Errorf("Expected the discriminator property petType, got %q", pet.DiscriminatorProperty)
+	}
+	// the mapped schemas lose their implicit names
+	expected := map[string]string{"kitty": "Cat", "doggo": "Dog", "Bird": "Bird"}
+	if !reflect.DeepEqual(pet.DiscriminatorMapping, expected) {
+		t.Errorf("Expected the discriminator mapping %v, got %v", expected, pet.DiscriminatorMapping)
+	}
+}
+
+func TestThatDiscriminatorsCantMapToNonObjects(t *testing.T) {
+	doc := `{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "title": "Pet",
+        "type": "object",
+        "properties": {
+            "pet": {
+                "oneOf": [{ "$ref": "#/$defs/Cat" }, { "$ref": "#/$defs/Size" }],
+                "discriminator": { "propertyName": "petType", "mapping": { "small": "#/$defs/Size" } }
+            }
+        },
+        "$defs": {
+            "Cat": { "type": "object", "properties": { "petType": { "type": "string" } } },
+            "Size": { "type": "string", "enum": ["small", "large"] }
+        }
+    }`
+	root, err := Parse(doc, &url.URL{Scheme: "file", Path: "/pet.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = New(root).CreateTypes("", "main", false)
+	if err == nil || !strings.Contains(err.Error(), `the mapping of "small" is Size, which isn't an object`) {
+		t.Errorf("Expected the enum target to be an error, got %v", err)
+	}
+}
+
+func TestThatBareDiscriminatorTargetsAreDefinitionsOfTheDraft(t *testing.T) {
+	for _, test := range []struct {
+		schemaType string
+		keyword    string
+	}{
+		{schemaType: "http://json-schema.org/draft-07/schema#", keyword: "definitions"},
+		{schemaType: "https://json-schema.org/draft/2020-12/schema", keyword: "$defs"},
+	} {
+		doc := fmt.Sprintf(`{
+            "$schema": %[1]q,
+            "title": "Shape",
+            "type": "object",
+            "properties": {
+                "shape": {
+                    "oneOf": [{ "$ref": "#/%[2]s/Circle" }, { "$ref": "#/%[2]s/Square" }],
+                    "discriminator": { "propertyName": "kind", "mapping": { "round": "Circle" } }
+                }
+            },
+            %[2]q: {
+                "Circle": { "type": "object", "properties": { "kind": { "type": "string" } } },
+                "Square": { "type": "object", "properties": { "kind": { "type": "string" } } }
+            }
+        }`, test.schemaType, test.keyword)
+		root, err := Parse(doc, &url.URL{Scheme: "file", Path: "/shape.json"})
+		if err != nil {
+			t.Fatalf("%s: %v", test.keyword, err)
+		}
+		g := New(root)
+		if err := g.CreateTypes("", "main", false); err != nil {
+			t.Fatalf("%s: failed to create structs: %v", test.keyword, err)
+		}
+		if typ := g.Structs["ShapeInterface"].DiscriminatorMapping["round"]; typ != "Circle" {
+			t.Errorf("%s: expected round to map to Circle, got %q", test.keyword, typ)
+		}
+	}
+}
+
+func TestThatValidationKeywordsBecomeConstraints(t *testing.T) {
+	doc := `{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "title": "Order",
+        "type": "object",
+        "properties": {
+            "id": { "type": "string", "minLength": 4, "pattern": "^[A-Z]+$" },
+            "look": { "type": "string", "pattern": "^(?=a)" },
+            "score": { "type": "number", "exclusiveMinimum": 0, "maximum": 10 },
+            "codes": { "type": "array", "uniqueItems": true, "items": { "type": "string", "maxLength": 5 } },
+            "tags": { "type": "array", "items": { "type": "string" }, "contains": { "pattern": "^x" } },
+            "notes": { "type": "array", "items": { "type": "string" }, "contains": {} },
+            "size": { "$ref": "#/$defs/Size" },
+            "name": { "type": "string" }
+        },
+        "$defs": {
+            "Size": { "type": "integer", "multipleOf": 2 }
+        }
+    }`
+	root, err := Parse(doc, &url.URL{Scheme: "file", Path: "/order.json"})
+	if err != nil {
+		t.Fatal("Failed to parse the schema: ", err)
+	}
+
+	g := New(root)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal("Failed to create structs: ", err)
+	}
+	fields := g.Structs["Order"].Fields
+
+	id := fields["Id"].Constraints
+	if id == nil || id.MinLength == nil || *id.MinLength != 4 || id.Pattern != "^[A-Z]+$" {
+		t.Errorf("Expected the minLength and pattern of id, got %+v", id)
+	}
+	if look := fields["Look"].Constraints; look == nil || look.Pattern != "" || look.UnsupportedPattern != "^(?=a)" {
+		t.Errorf("Expected the lookahead pattern of look to be unsupported, got %+v", look)
+	}
+	score := fields["Score"].Constraints
+	if score == nil || score.ExclusiveMinimum == nil || *score.ExclusiveMinimum != 0 || score.Maximum == nil || *score.Maximum != 10 {
+		t.Errorf("Expected the bounds of score, got %+v", score)
+	}
+	codes := fields["Codes"].Constraints
+	if codes == nil || !codes.UniqueItems || codes.Items == nil || codes.Items.MaxLength == nil || *codes.Items.MaxLength != 5 {
+		t.Errorf("Expected uniqueItems and the item constraints of codes, got %+v", codes)
+	}
+	if tags := fields["Tags"].Constraints; tags == nil || tags.Contains == nil || tags.Contains.Pattern != "^x" {
+		t.Errorf("Expected the contains constraints of tags, got %+v", tags)
+	}
+	if notes := fields["Notes"].Constraints; notes == nil || notes.Contains == nil || !notes.Contains.isEmpty() {
+		t.Errorf("Expected empty contains constraints requiring an item of notes, got %+v", notes)
+	}
+	if size := fields["Size"].Constraints; size == nil || size.MultipleOf == nil || *size.MultipleOf != 2 {
+		t.Errorf("Expected the constraints of the referenced schema of size, got %+v", size)
+	}
+	if name := fields["Name"].Constraints; name != nil {
+		t.Errorf("Expected no constraints on name, got %+v", name)
+	}
+}
+
+func TestPatternPropertiesGeneration(t *testing.T) {
+	doc := `{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "title": "Catalog",
+        "type": "object",
+        "properties": {
+            "name": { "type": "string" },
+            "prices": {
+                "type": "object",
+                "propertyNames": { "$ref": "#/$defs/Region" },
+                "additionalProperties": { "type": "number" }
+            }
+        },
+        "patternProperties": {
+            "^x-": { "type": "string" },
+            "^n_": { "type": "integer" }
+        },
+        "additionalProperties": false,
+        "$defs": {
+            "Region": { "enum": [ "eu", "us" ] }
+        }
+    }`
+	root, err := Parse(doc, &url.URL{Scheme: "file", Path: "/catalog.json"})
+	if err != nil {
+		t.Fatal("Failed to parse the schema: ", err)
+	}
+	if root.PatternProperties["^x-"].PathElement != "patternProperties/^x-" {
+		t.Errorf("Expected the path element patternProperties/^x-, got %q", root.PatternProperties["^x-"].PathElement)
+	}
+
+	g := New(root)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal("Failed to create structs: ", err)
+	}
+	catalog := g.Structs["Catalog"]
+
+	testField(catalog.Fields["PatternProperties1"], "-", "PatternProperties1", "map[string]int", false, t)
+	testField(catalog.Fields["PatternProperties2"], "-", "PatternProperties2", "map[string]string", false, t)
+	testField(catalog.Fields["Prices"], "prices", "Prices", "map[Region]float64", false, t)
+
+	expected := []PatternProperty{
+		{Pattern: "^n_", Field: "PatternProperties1", ValueType: "int"},
+		{Pattern: "^x-", Field: "PatternProperties2", ValueType: "string"},
+	}
+	if !reflect.DeepEqual(catalog.PatternProperties, expected) {
+		t.Errorf("Expected the pattern properties %v, got %v", expected, catalog.PatternProperties)
+	}
+	if !catalog.GenerateCode || catalog.AdditionalType != "false" {
+		t.Errorf("Expected marshal code without additional properties, got GenerateCode %v and AdditionalType %q", catalog.GenerateCode, catalog.AdditionalType)
+	}
+}
 
-		if len(structs) != test.structs {
-			t.Errorf("Expected %d structs, got %d", test.structs, len(structs))
+func TestThatUnsupportedPatternPropertiesAreAnError(t *testing.T) {
+	doc := `{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "title": "Headers",
+        "type": "object",
+        "patternProperties": {
+            "^(?!x-)": { "type": "string" }
+        }
+    }`
+	root, err := Parse(doc, &url.URL{Scheme: "file", Path: "/headers.json"})
+	if err != nil {
+		t.Fatal("Failed to parse the schema: ", err)
+	}
+	if err := New(root).CreateTypes("", "main", false); err == nil {
+		t.Error("Expected an error for a lookahead in patternProperties")
+	}
+}
+
+func TestConstGeneration(t *testing.T) {
+	doc := `{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "title": "Envelope",
+        "type": "object",
+        "properties": {
+            "event": { "oneOf": [ { "$ref": "#/$defs/Cancel" }, { "$ref": "#/$defs/Start" } ] }
+        },
+        "$defs": {
+            "Cancel": {
+                "type": "object",
+                "properties": {
+                    "eventType": { "type": "string", "const": "CancelTariffication" },
+                    "version": { "$ref": "#/$defs/Version" },
+                    "shape": { "const": { "a": [ 1, 2 ] } }
+                }
+            },
+            "Start": {
+                "type": "object",
+                "properties": {
+                    "eventType": { "const": "StartTariffication" },
+                    "ratio": { "const": 0.5 }
+                }
+            },
+            "Version": { "const": 2 }
+        }
+    }`
+	root, err := Parse(doc, &url.URL{Scheme: "file", Path: "/envelope.json"})
+	if err != nil {
+		t.Fatal("Failed to parse the schema: ", err)
+	}
+
+	g := New(root)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal("Failed to create structs: ", err)
+	}
+
+	testField(g.Structs["Cancel"].Fields["EventType"], "eventType", "EventType", "CancelEventType", false, t)
+	testField(g.Structs["Cancel"].Fields["Version"], "version", "Version", "Version", false, t)
+	testField(g.Structs["Start"].Fields["EventType"], "eventType", "EventType", "StartEventType", false, t)
+
+	tests := []struct {
+		name      string
+		constType string
+		value     any
+	}{
+		{"CancelEventType", "string", "CancelTariffication"},
+		{"StartEventType", "string", "StartTariffication"},
+		{"Version", "int", 2},
+		{"StartRatio", "float64", 0.5},
+		{"CancelShape", "struct{}", `{"a":[1,2]}`},
+	}
+	for _, test := range tests {
+		s, ok := g.Structs[test.name]
+		if !ok {
+			t.Errorf("The %s type should have been made, but only types %s were made.", test.name, strings.Join(getStructNamesFromMap(g.Structs), ", "))
+			continue
 		}
+		if s.ConstType != test.constType || s.ConstValue != test.value {
+			t.Errorf("Expected %s to be the %s %v, got the %s %v", test.name, test.constType, test.value, s.ConstType, s.ConstValue)
+		}
+	}
+
+	event := g.Structs["EventInterface"]
+	if event.DiscriminatorProperty != "eventType" {
+		t.Errorf("Expected the discriminator property eventType, got %q", event.DiscriminatorProperty)
+	}
+	expected := map[string]string{"CancelTariffication": "Cancel", "StartTariffication": "Start"}
+	if !reflect.DeepEqual(event.DiscriminatorMapping, expected) {
+		t.Errorf("Expected the discriminator mapping %v, got %v", expected, event.DiscriminatorMapping)
+	}
+}
+
+func TestConditionalGeneration(t *testing.T) {
+	doc := `{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "title": "Payment",
+        "type": "object",
+        "required": [ "type" ],
+        "properties": {
+            "type": { "enum": [ "card", "cash" ] },
+            "amount": { "type": "number" }
+        },
+        "if": { "properties": { "type": { "const": "card" } }, "required": [ "type" ] },
+        "then": { "required": [ "card" ], "properties": { "card": { "type": "string" } } },
+        "else": { "required": [ "receipt" ], "properties": { "receipt": { "type": "string" } } },
+        "allOf": [
+            { "if": { "properties": { "amount": { "minimum": 5 } } }, "then": { "required": [ "type" ] } }
+        ]
+    }`
+	root, err := Parse(doc, &url.URL{Scheme: "file", Path: "/payment.json"})
+	if err != nil {
+		t.Fatal("Failed to parse the schema: ", err)
+	}
+	if root.Then.PathElement != "then" {
+		t.Errorf("Expected the path element then, got %q", root.Then.PathElement)
+	}
+
+	g := New(root)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal("Failed to create structs: ", err)
+	}
+	payment := g.Structs["Payment"]
+
+	testField(payment.Fields["Card"], "card", "Card", "*string", false, t)
+	testField(payment.Fields["Receipt"], "receipt", "Receipt", "*string", false, t)
+
+	expected := []Condition{
+		{
+			Path: "#/if",
+			If:   []ConditionTest{{Property: "type", Values: []any{"card"}, Required: true}},
+			Then: []string{"card"},
+			Else: []string{"receipt"},
+		},
+		{
+			Path:        "#/allOf/0/if",
+			Then:        []string{"type"},
+			Unsupported: true,
+		},
+	}
+	if !reflect.DeepEqual(payment.Conditions, expected) {
+		t.Errorf("Expected the conditions %+v, got %+v", expected, payment.Conditions)
+	}
+}
+
+func TestThatUnsupportedConditionsAreWarnings(t *testing.T) {
+	doc := `{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "title": "Payment",
+        "type": "object",
+        "properties": {
+            "amount": { "type": "number" },
+            "type": { "type": "string" }
+        },
+        "allOf": [
+            { "if": { "properties": { "type": { "const": "card" } } }, "then": { "required": [ "card" ], "properties": { "card": { "type": "string" } } } },
+            { "if": { "properties": { "amount": { "minimum": 5 } } }, "then": { "required": [ "receipt" ], "properties": { "receipt": { "type": "string" } } } }
+        ]
+    }`
+	root, err := Parse(doc, &url.URL{Scheme: "file", Path: "/payment.json"})
+	if err != nil {
+		t.Fatal("Failed to parse the schema: ", err)
+	}
+	g := New(root)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal("Failed to create structs: ", err)
+	}
+	if len(g.Diagnostics) != 1 {
+		t.Fatalf("Expected a warning for the unsupported condition, got %v", g.Diagnostics)
+	}
+	d := g.Diagnostics[0]
+	if d.Severity != SeverityWarning || d.Pointer != "#/allOf/1" || d.Keyword != "if" || d.Line != 11 {
+		t.Errorf("Expected a warning at the if of #/allOf/1 on line 11, got %v line %d", d, d.Line)
+	}
+	if g.Diagnostics.Err(false) != nil || g.Diagnostics.Err(true) == nil {
+		t.Errorf("Expected the warning to fail in strict mode only")
+	}
+}
+
+func TestGoExtensions(t *testing.T) {
+	root, err := Parse(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "title": "Order",
+    "type": "object",
+    "required": ["id"],
+    "properties": {
+        "id": {
+            "type": "string",
+            "x-go-type": "uuid.UUID",
+            "x-go-type-import": "github.com/google/uuid",
+            "x-go-name": "ID",
+            "x-go-tag": "db:\"id\""
+        },
+        "amount": {
+            "type": "string",
+            "x-go-type": "decimal.Decimal",
+            "x-go-type-import": { "path": "github.com/shopspring/decimal", "name": "decimal" },
+            "minLength": 1
+        },
+        "note": { "type": "string", "x-omitempty": false },
+        "customer": { "$ref": "#/definitions/customer" },
+        "ttl": { "$ref": "#/definitions/duration" }
+    },
+    "definitions": {
+        "customer": { "type": "object", "x-go-name": "Client", "properties": { "name": { "type": "string" } } },
+        "duration": { "type": "string", "x-go-type": "time.Duration", "x-go-type-import": "time" }
+    }
+}`, &url.URL{Scheme: "file", Path: "/order.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := New(root)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal("Failed to create structs: ", err)
+	}
+	order := g.Structs["Order"]
+
+	testField(order.Fields["ID"], "id", "ID", "uuid.UUID", true, t)
+	testField(order.Fields["Amount"], "amount", "Amount", "*decimal.Decimal", false, t)
+	testField(order.Fields["Customer"], "customer", "Customer", "*Client", false, t)
+	testField(order.Fields["Ttl"], "ttl", "Ttl", "*time.Duration", false, t)
+	if _, ok := g.Structs["Client"]; !ok {
+		t.Errorf("Expected the customer definition to be named Client, got %v", g.Structs)
+	}
+	if order.Fields["ID"].Tags != `db:"id"` {
+		t.Errorf("Expected the db tag, got %q", order.Fields["ID"].Tags)
+	}
+	if omitempty := order.Fields["Note"].Omitempty; omitempty == nil || *omitempty {
+		t.Errorf("Expected omitempty to be turned off, got %v", omitempty)
+	}
+	if order.Fields["Amount"].Constraints != nil {
+		t.Errorf("Expected no constraints for the x-go-type, got %+v", order.Fields["Amount"].Constraints)
+	}
+
+	expected := map[string]string{
+		"github.com/google/uuid":        "",
+		"github.com/shopspring/decimal": "decimal",
+		"time":                          "",
+	}
+	if !reflect.DeepEqual(g.Imports, expected) {
+		t.Errorf("Expected the imports %v, got %v", expected, g.Imports)
+	}
+}
 
-		if len(aliases) != test.aliases {
-			t.Errorf("Expected %d type aliases, got %d", test.aliases, len(aliases))
+func TestNullableGeneration(t *testing.T) {
+	schema := `{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "title": "Patch",
+    "type": "object",
+    "properties": {
+        "name": { "type": ["string", "null"] },
+        "address": { "oneOf": [{ "$ref": "#/definitions/address" }, { "type": "null" }] },
+        "at": { "oneOf": [{ "type": "string", "format": "date-time" }, { "type": "null" }] },
+        "nothing": { "type": "null" }
+    },
+    "required": ["name", "address"],
+    "definitions": {
+        "address": { "type": "object", "properties": { "street": { "type": "string" } } }
+    }
+}`
+	tests := []struct {
+		generic  bool
+		expected map[string]string
+	}{
+		{
+			generic: false,
+			expected: map[string]string{
+				"Name":    "*string",
+				"Address": "*Address",
+				"At":      "*time.Time",
+				"Nothing": "interface{}",
+			},
+		},
+		{
+			generic: true,
+			expected: map[string]string{
+				"Name":    "Nullable[string]",
+				"Address": "Nullable[Address]",
+				"At":      "Nullable[time.Time]",
+				"Nothing": "interface{}",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		root, err := Parse(schema, &url.URL{Scheme: "file", Path: "/patch.json"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := New(root)
+		g.GenericNullable = test.generic
+		if err := g.CreateTypes("", "main", false); err != nil {
+			t.Fatal("Failed to create structs: ", err)
+		}
+		patch := g.Structs["Patch"]
+		for name, typ := range test.expected {
+			if f := patch.Fields[name]; f.Type != typ {
+				t.Errorf("generic %v: expected %s to be a %s, got %s", test.generic, name, typ, f.Type)
+			}
 		}
+		if !patch.Fields["Name"].Nullable || patch.Fields["Nothing"].Nullable {
+			t.Errorf("generic %v: expected only the null unions to be nullable, got %+v", test.generic, patch.Fields)
+		}
+		if _, ok := g.Helpers["Nullable"]; ok != test.generic {
+			t.Errorf("generic %v: expected the Nullable helper only when generic, got %v", test.generic, g.Helpers)
+		}
+	}
+}
+
+func TestReferenceCycles(t *testing.T) {
+	tests := []struct {
+		name     string
+		defs     string
+		expected map[string]string
+		err      string
+	}{
+		{
+			name:     "chained references",
+			defs:     `"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/c"}, "c": {"type": "object", "properties": {"x": {"type": "string"}}}`,
+			expected: map[string]string{"Root.A": "*C"},
+		},
+		{
+			name: "references to themselves",
+			defs: `"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}`,
+			err:  "reference cycle #/$defs/a -> #/$defs/b -> #/$defs/a",
+		},
+		{
+			name:     "arrays of each other",
+			defs:     `"a": {"type": "array", "items": {"$ref": "#/$defs/b"}}, "b": {"type": "array", "items": {"$ref": "#/$defs/a"}}`,
+			expected: map[string]string{"Root.A": "A", "A": "[][]A"},
+		},
+		{
+			name:     "array of itself",
+			defs:     `"a": {"type": "array", "items": {"$ref": "#/$defs/a"}}`,
+			expected: map[string]string{"Root.A": "A", "A": "[]A"},
+		},
+		{
+			name:     "map of itself",
+			defs:     `"a": {"type": "array", "items": {"type": "object", "additionalProperties": {"$ref": "#/$defs/a"}}}`,
+			expected: map[string]string{"Root.A": "A", "A": "[]map[string]A"},
+		},
+		{
+			name:     "array of structs",
+			defs:     `"a": {"type": "array", "items": {"$ref": "#/$defs/node"}}, "node": {"type": "object", "properties": {"children": {"$ref": "#/$defs/a"}}}`,
+			expected: map[string]string{"Root.A": "[]*Node", "Node.Children": "[]*Node"},
+		},
+		{
+			name:     "required struct",
+			defs:     `"a": {"type": "object", "required": ["next"], "properties": {"next": {"$ref": "#/$defs/a"}}}`,
+			expected: map[string]string{"Root.A": "*A", "A.Next": "*A"},
+		},
+	}
 
-		if test.gotype != aliases["Root"].Type {
-			t.Errorf("Expected Root type %q, got %q", test.gotype, aliases["Root"].Type)
+	for _, test := range tests {
+		root, err := Parse(`{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {"a": {"$ref": "#/$defs/a"}},
+			"$defs": {`+test.defs+`}
+		}`, &url.URL{Scheme: "file", Path: "/cycle.json"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := New(root)
+		err = g.CreateTypes("", "main", false)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: expected the error %q, got %v", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		for name, typ := range test.expected {
+			actual := g.Structs[name].Type
+			if strct, field, ok := strings.Cut(name, "."); ok {
+				actual = g.Structs[strct].Fields[field].Type
+			}
+			if actual != typ {
+				t.Errorf("%s: expected the type of %s to be %s, got %q", test.name, name, typ, actual)
+			}
 		}
 	}
 }
 
-// Root is an example of a generated type.
-type Root struct {
-	Name interface{} `json:"name,omitempty"`
+func TestSiblingKeywordsOfReferences(t *testing.T) {
+	root, err := Parse(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"id": {"$ref": "#/$defs/code", "description": "Order id.", "readOnly": true, "maxLength": 8},
+			"status": {"$ref": "#/$defs/code", "default": "new", "deprecated": true},
+			"customer": {"$ref": "#/$defs/person", "properties": {"vip": {"type": "boolean"}}, "required": ["name"]},
+			"contact": {"$ref": "#/$defs/person"}
+		},
+		"$defs": {
+			"code": {"type": "string", "minLength": 2, "maxLength": 20},
+			"person": {"type": "object", "description": "A person.", "properties": {"name": {"type": "string"}}},
+			"employee": {"$ref": "#/$defs/person", "properties": {"boss": {"$ref": "#/$defs/employee"}}}
+		}
+	}`, &url.URL{Scheme: "file", Path: "/siblings.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(root)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal(err)
+	}
+
+	fields := g.Structs["Root"].Fields
+	if d := fields["Id"].Description; d != "Order id.\nRead-only: set by the owner of the resource." {
+		t.Errorf("expected the description and readOnly of the id, got %q", d)
+	}
+	if c := fields["Id"].Constraints; c == nil || c.MinLength == nil || *c.MinLength != 2 || c.MaxLength == nil || *c.MaxLength != 8 {
+		t.Errorf("expected the constraints of the code with the maxLength of the id, got %+v", c)
+	}
+	if d := fields["Status"].Description; d != `@deprecated: Default: "new"` {
+		t.Errorf("expected the status to be deprecated with its default, got %q", d)
+	}
+	if typ := fields["Customer"].Type; typ != "*Customer" {
+		t.Errorf("expected a derived type for the customer, got %s", typ)
+	}
+	customer := g.Structs["Customer"]
+	if customer.Description != "A person." || !customer.Fields["Name"].Required || customer.Fields["Vip"].Type != "*bool" {
+		t.Errorf("expected the customer to be a person with a vip flag and a required name, got %+v", customer)
+	}
+	if typ := fields["Contact"].Type; typ != "*Person" {
+		t.Errorf("expected the contact to be a person, got %s", typ)
+	}
+	if typ := g.Structs["Employee"].Fields["Boss"].Type; typ != "*Employee" {
+		t.Errorf("expected the boss of an employee to be an employee, got %s", typ)
+	}
+	if _, ok := g.Structs["Employee"].Fields["Name"]; !ok {
+		t.Error("expected an employee to have the name of a person")
+	}
 }
@@ -2,4 +2,13 @@ module github.com/Graff913/generate-go-json-schema
 
 go 1.23.0
 
-require go.mongodb.org/mongo-driver v1.17.0 // indirect
+require (
+	go.mongodb.org/mongo-driver v1.17.0
+	golang.org/x/tools v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/mod v0.23.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+)
@@ -0,0 +1,244 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// InferOptions configure Infer.
+type InferOptions struct {
+	// Title is the title of the root schema, which names its Go type.
+	Title string
+	// MaxEnumValues is the most distinct values of the strings at a position to make them an enum, 0 for none. Each
+	// value has to be seen twice on average, so that a few samples don't make every string an enum.
+	MaxEnumValues int
+}
+
+// InferFiles returns a 2020-12 JSON schema valid for every JSON value of files, see Infer. A file holds one JSON
+// document or several, e.g. one per line (NDJSON).
+func InferFiles(files []string, opts InferOptions) ([]byte, error) {
+	var samples []json.RawMessage
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, &SchemaError{File: file, Message: "failed to read the sample", Err: err}
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		for {
+			var v json.RawMessage
+			err := dec.Decode(&v)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, &SchemaError{File: file, Message: "failed to parse the sample", Err: err}
+			}
+			samples = append(samples, v)
+		}
+	}
+	if len(samples) == 0 {
+		return nil, errors.New("no samples to infer a schema from")
+	}
+	return Infer(samples, opts)
+}
+
+// Infer returns a 2020-12 JSON schema valid for all the samples, JSON documents. The types seen at the same position
+// are merged, e.g. a property that's a string or null has the type ["string", "null"], and the properties present in
+// all the objects are required. Strings that are all date-times, dates or UUIDs have that format, the other strings
+// may be enums, see InferOptions.MaxEnumValues. The schema ends with a newline.
+func Infer(samples []json.RawMessage, opts InferOptions) ([]byte, error) {
+	s := newShape()
+	for i, sample := range samples {
+		v, err := decodeOrdered(sample)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the sample %d: %w", i+1, err)
+		}
+		s.observe(v)
+	}
+
+	root := newOrderedObject()
+	root.set("$schema", metaSchemaURIs[Draft202012])
+	if opts.Title != "" {
+		root.set("title", opts.Title)
+	}
+	root.merge(s.schema(opts))
+
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// The JSON types in the order they're written.
+var jsonTypes = []string{"object", "array", "string", "integer", "number", "boolean", "null"}
+
+// The formats detected in strings.
+var stringFormats = []struct {
+	name  string
+	valid func(s string) bool
+}{
+	{"date-time", func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	}},
+	{"date", func(s string) bool {
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	}},
+	{"uuid", regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString},
+}
+
+// shape accumulates the values seen at the same position of the samples.
+type shape struct {
+	types map[string]bool
+	// objects is the number of objects seen, properties the values of their keys in the order they were first seen
+	objects    int
+	properties *orderedObject
+	// values seen in arrays
+	items *shape
+	// strings is the number of strings seen, values their distinct values in the order they were first seen and
+	// formats the ones they all have
+	strings int
+	values  []string
+	seen    map[string]bool
+	formats map[string]bool
+	// present is the number of objects the property of the shape is in
+	present int
+}
+
+func newShape() *shape {
+	return &shape{types: make(map[string]bool), properties: newOrderedObject(), seen: make(map[string]bool)}
+}
+
+// observe merges v, a value decoded by decodeOrdered, into the shape.
+func (s *shape) observe(v any) {
+	switch v := v.(type) {
+	case nil:
+		s.types["null"] = true
+	case bool:
+		s.types["boolean"] = true
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			s.types["number"] = true
+		} else {
+			s.types["integer"] = true
+		}
+	case string:
+		s.types["string"] = true
+		s.observeString(v)
+	case *orderedObject:
+		s.types["object"] = true
+		s.objects++
+		for _, key := range v.keys {
+			p := s.property(key)
+			p.observe(v.values[key])
+			p.present++
+		}
+	case []any:
+		s.types["array"] = true
+		if s.items == nil {
+			s.items = newShape()
+		}
+		for _, item := range v {
+			s.items.observe(item)
+		}
+	}
+}
+
+func (s *shape) observeString(v string) {
+	if s.strings == 0 {
+		s.formats = make(map[string]bool)
+		for _, f := range stringFormats {
+			s.formats[f.name] = true
+		}
+	}
+	s.strings++
+	for _, f := range stringFormats {
+		if s.formats[f.name] && !f.valid(v) {
+			delete(s.formats, f.name)
+		}
+	}
+	if !s.seen[v] {
+		s.seen[v] = true
+		s.values = append(s.values, v)
+	}
+}
+
+// property returns the shape of the values of the property key.
+func (s *shape) property(key string) *shape {
+	if p, ok := s.properties.values[key].(*shape); ok {
+		return p
+	}
+	p := newShape()
+	s.properties.set(key, p)
+	return p
+}
+
+// schema returns the JSON schema of the values seen.
+func (s *shape) schema(opts InferOptions) *orderedObject {
+	schema := newOrderedObject()
+	// integers are numbers as well
+	if s.types["number"] {
+		delete(s.types, "integer")
+	}
+	var types []string
+	for _, t := range jsonTypes {
+		if s.types[t] {
+			types = append(types, t)
+		}
+	}
+	switch len(types) {
+	case 0:
+		// nothing seen, e.g. the items of empty arrays
+		return schema
+	case 1:
+		schema.set("type", types[0])
+	default:
+		schema.set("type", types)
+	}
+
+	if s.types["object"] {
+		properties := newOrderedObject()
+		var required []string
+		for _, key := range s.properties.keys {
+			p := s.properties.values[key].(*shape)
+			properties.set(key, p.schema(opts))
+			if p.present == s.objects {
+				required = append(required, key)
+			}
+		}
+		if len(properties.keys) > 0 {
+			schema.set("properties", properties)
+		}
+		if len(required) > 0 {
+			schema.set("required", required)
+		}
+	}
+	if s.types["array"] && s.items != nil && len(s.items.types) > 0 {
+		schema.set("items", s.items.schema(opts))
+	}
+	if s.types["string"] {
+		for _, f := range stringFormats {
+			if s.formats[f.name] {
+				schema.set("format", f.name)
+				return schema
+			}
+		}
+		// null isn't one of the values
+		if len(types) == 1 && len(s.values) <= opts.MaxEnumValues && s.strings >= 2*len(s.values) {
+			schema.set("enum", s.values)
+		}
+	}
+	return schema
+}
@@ -0,0 +1,75 @@
+package generate
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestThatSchemasCanBeInferredFromSamples(t *testing.T) {
+	samples := `{"id": "6f1c2b9e-8d4a-4c1e-9f3b-2a7d5e8c1b40", "status": "open", "at": "2024-01-02T03:04:05Z", "total": 12, "customer": {"email": null}}
+{"id": "0b7e3c2a-1f5d-4e8b-a9c6-3d2f1e0b9a87", "status": "closed", "at": "2024-01-03T03:04:05Z", "total": 12.5, "customer": {"email": "b@x.io"}, "note": "a"}
+{"id": "c3a8f1e2-7b6d-4f9a-8e2c-1d0b3a5f7e96", "status": "open", "at": "2024-01-04T03:04:05Z", "total": 3, "customer": {}, "note": "b"}
+{"id": "a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c5d", "status": "closed", "at": "2024-01-05T03:04:05Z", "total": 1, "customer": {}, "note": "c"}
+`
+	path := filepath.Join(t.TempDir(), "orders.ndjson")
+	if err := os.WriteFile(path, []byte(samples), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	b, err := InferFiles([]string{path}, InferOptions{Title: "Order", MaxEnumValues: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, diagnostics, err := ParseWithOptions(string(b), &url.URL{Scheme: "file", Path: "/order.json"}, ParseOptions{Strict: true})
+	if err != nil || len(diagnostics) > 0 {
+		t.Fatalf("expected a valid schema, got %v %v:\n%s", diagnostics, err, b)
+	}
+
+	if expected := []string{"id", "status", "at", "total", "customer", "note"}; !reflect.DeepEqual(root.PropertyKeys(), expected) {
+		t.Errorf("expected the properties in the order of the samples %v, got %v", expected, root.PropertyKeys())
+	}
+	if expected := []string{"id", "status", "at", "total", "customer"}; !reflect.DeepEqual(root.Required, expected) {
+		t.Errorf("expected the properties of every sample to be required %v, got %v", expected, root.Required)
+	}
+	if note := root.Properties["note"]; len(note.EnumValue) != 0 {
+		t.Errorf("expected strings seen once not to be an enum, got %v", note.EnumValue)
+	}
+
+	g := New(root)
+	// the inferred format is checked below, its Go type is up to the formats
+	delete(g.Formats, "uuid")
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal("Failed to create structs: ", err)
+	}
+	order := g.Structs["Order"]
+	testField(order.Fields["Id"], "id", "Id", "string", true, t)
+	testField(order.Fields["Status"], "status", "Status", "Status", true, t)
+	testField(order.Fields["At"], "at", "At", "time.Time", true, t)
+	testField(order.Fields["Total"], "total", "Total", "float64", true, t)
+	testField(g.Structs["Customer"].Fields["Email"], "email", "Email", "*string", false, t)
+	if root.Properties["id"].FormatValue != "uuid" {
+		t.Errorf("expected the uuid format, got %v", root.Properties["id"].FormatValue)
+	}
+}
+
+func TestThatInferredTypesAreMerged(t *testing.T) {
+	b, err := Infer([]json.RawMessage{[]byte(`[1, "a", null]`), []byte(`[2.5, []]`)}, InferOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Type  string `json:"type"`
+		Items struct {
+			Type []string `json:"type"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"array", "string", "number", "null"}; schema.Type != "array" || !reflect.DeepEqual(schema.Items.Type, expected) {
+		t.Errorf("expected an array of %v, got:\n%s", expected, b)
+	}
+}
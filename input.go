@@ -1,63 +1,79 @@
 package generate
 
 import (
-	"encoding/json"
-	"errors"
+	"bytes"
 	"fmt"
 	"net/url"
 	"os"
 	"path"
-	"path/filepath"
+	"strconv"
 )
 
-// ReadInputFiles from disk and convert to JSON schema.
+// ReadInputFiles from disk and convert to JSON schema. YAML files are accepted by extension, each document of a
+// multi-document YAML file becomes a separate schema.
 func ReadInputFiles(inputFiles []AnalysisFile, schemaKeyRequired bool) ([]*Schema, error) {
-	schemas := make([]*Schema, len(inputFiles))
-	for i, file := range inputFiles {
-		b, err := os.ReadFile(file.Path)
-		if err != nil {
-			return nil, errors.New("failed to read the input file with error " + err.Error())
-		}
+	schemas, _, err := ReadInputFilesWithOptions(inputFiles, ParseOptions{SchemaKeyRequired: schemaKeyRequired})
+	return schemas, err
+}
 
-		abPath, err := filepath.Abs(file.Path) // получаем абсолютный путь с разделителями ОС
+// ReadInputFilesWithOptions is ReadInputFiles returning the diagnostics of all the schemas read. The error joins the
+// diagnostics that fail with the options, see Diagnostics.Err. The files are read with ParseOptions.Loader.
+func ReadInputFilesWithOptions(inputFiles []AnalysisFile, opts ParseOptions) ([]*Schema, Diagnostics, error) {
+	schemas := make([]*Schema, 0, len(inputFiles))
+	var diagnostics Diagnostics
+	loader := opts.Loader
+	if loader == nil {
+		loader = FileLoader{}
+	}
+	for _, file := range inputFiles {
+		uri := file.URI
+		if uri == "" {
+			var ok bool
+			if uri, ok = loader.Resolve("", file.Path); !ok {
+				return nil, diagnostics, &SchemaError{File: file.Path, Message: "the input file can't be loaded"}
+			}
+		}
+		b, err := loader.Load(uri)
 		if err != nil {
-			return nil, errors.New("failed to normalise input path with error " + err.Error())
+			return nil, diagnostics, &SchemaError{File: file.Path, Message: "failed to read the input file", Err: err}
 		}
 
-		fixedPath := filepath.ToSlash(abPath) // преобразуем \ в / (Windows) или оставляем / (Unix)
-
-		fileURI := url.URL{
-			Scheme: "file",
-			Path:   fixedPath,
+		fileURI, err := url.Parse(uri)
+		if err != nil {
+			return nil, diagnostics, &SchemaError{File: file.Path, Message: "invalid input file URI", Err: err}
 		}
 
-		schemas[i], err = ParseWithSchemaKeyRequired(string(b), &fileURI, schemaKeyRequired)
+		docs, err := readDocuments(file.Path, b)
 		if err != nil {
-			var syntaxError *json.SyntaxError
-			if errors.As(err, &syntaxError) {
-				line, character, lcErr := lineAndCharacter(b, int(syntaxError.Offset))
-				errStr := fmt.Sprintf("cannot parse JSON schema due to a syntax error at %s line %d, character %d: %v\n", file, line, character, syntaxError.Error())
-				if lcErr != nil {
-					errStr += fmt.Sprintf("couldn't find the line and character position of the error due to error %v\n", lcErr)
-				}
-				return nil, errors.New(errStr)
+			return nil, diagnostics, err
+		}
+		for i, doc := range docs {
+			docURI := *fileURI
+			if i > 0 {
+				// the first document is addressed by the file, the following ones by their index
+				docURI.RawQuery = "document=" + strconv.Itoa(i)
 			}
-			var unmarshalTypeError *json.UnmarshalTypeError
-			if errors.As(err, &unmarshalTypeError) {
-				line, character, lcErr := lineAndCharacter(b, int(unmarshalTypeError.Offset))
-				errStr := fmt.Sprintf("the JSON type '%v' cannot be converted into the Go '%v' type on struct '%s', field '%v'. See input file %s line %d, character %d\n", unmarshalTypeError.Value, unmarshalTypeError.Type.Name(), unmarshalTypeError.Struct, unmarshalTypeError.Field, file, line, character)
-				if lcErr != nil {
-					errStr += fmt.Sprintf("couldn't find the line and character position of the error due to error %v\n", lcErr)
-				}
-				return nil, errors.New(errStr)
+			schema, d, err := parseInputFile(file, doc.JSON, doc.position, &docURI, opts)
+			diagnostics = append(diagnostics, d...)
+			if err != nil {
+				return nil, diagnostics, err
 			}
-			return nil, fmt.Errorf("failed to parse the input JSON schema file %s with error %v", file, err)
+			schemas = append(schemas, schema)
 		}
+	}
+
+	return schemas, diagnostics, nil
+}
 
-		schemas[i].Root = file.Root
+// parseInputFile parses the JSON of an input file, position maps an index of b to the line and character in the file.
+func parseInputFile(file AnalysisFile, b []byte, position func(index int) (int, int, error), uri *url.URL, opts ParseOptions) (*Schema, Diagnostics, error) {
+	schema, diagnostics, err := parse(b, uri, opts, file.Path, position)
+	if err != nil {
+		return nil, diagnostics, err
 	}
 
-	return schemas, nil
+	schema.Root = file.Root
+	return schema, diagnostics, nil
 }
 
 func lineAndCharacter(bytes []byte, offset int) (line int, character int, err error) {
@@ -84,6 +100,16 @@ func lineAndCharacter(bytes []byte, offset int) (line int, character int, err er
 	return 0, 0, fmt.Errorf("couldn't find offset %d in %d bytes", offset, len(bytes))
 }
 
+// lineAndColumn returns the position of the byte at index in b, both the line and the column count from 1.
+func lineAndColumn(b []byte, index int) (line int, column int, err error) {
+	if index < 0 || index >= len(b) {
+		return 0, 0, fmt.Errorf("couldn't find index %d in %d bytes", index, len(b))
+	}
+	line = 1 + bytes.Count(b[:index], []byte("\n"))
+	column = index - bytes.LastIndexByte(b[:index], '\n')
+	return line, column, nil
+}
+
 func abs(name string) (string, error) {
 	if path.IsAbs(name) {
 		return name, nil
@@ -0,0 +1,60 @@
+package generate
+
+import "testing"
+
+func TestLineAndColumn(t *testing.T) {
+	tests := []struct {
+		In             []byte
+		Index          int
+		ExpectedLine   int
+		ExpectedColumn int
+		ExpectedError  bool
+	}{
+		{
+			In:             []byte("Line 1\nLine 2"),
+			Index:          7,
+			ExpectedLine:   2,
+			ExpectedColumn: 1,
+		},
+		{
+			In:             []byte("Line 1\r\nLine 2"),
+			Index:          8,
+			ExpectedLine:   2,
+			ExpectedColumn: 1,
+		},
+		{
+			In:             []byte("Line 1\nLine 2"),
+			Index:          6,
+			ExpectedLine:   1,
+			ExpectedColumn: 7,
+		},
+		{
+			In:             []byte("Line 1\nLine 2"),
+			Index:          0,
+			ExpectedLine:   1,
+			ExpectedColumn: 1,
+		},
+		{
+			In:            []byte("Line 1\nLine 2"),
+			Index:         200,
+			ExpectedError: true,
+		},
+		{
+			In:            []byte("Line 1\nLine 2"),
+			Index:         -1,
+			ExpectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		actualLine, actualColumn, err := lineAndColumn(test.In, test.Index)
+		if (err != nil) != test.ExpectedError {
+			t.Errorf("For %q at index %d, expected an error: %v, got %v", test.In, test.Index, test.ExpectedError, err)
+			continue
+		}
+
+		if actualLine != test.ExpectedLine || actualColumn != test.ExpectedColumn {
+			t.Errorf("For %q at index %d, expected %d:%d, but got %d:%d", test.In, test.Index, test.ExpectedLine, test.ExpectedColumn, actualLine, actualColumn)
+		}
+	}
+}
@@ -1,11 +1,41 @@
 package generate
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 )
 
+// Draft is a version of the JSON schema specification.
+type Draft int
+
+// The JSON schema drafts, in order of publication.
+const (
+	DraftUnknown Draft = iota
+	Draft04
+	Draft06
+	Draft07
+	Draft201909
+	Draft202012
+)
+
+// Components holds the re-usable schemas of an OpenAPI document.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Discriminator selects the schema of a value by one of its properties.
+type Discriminator struct {
+	PropertyName string `json:"propertyName"`
+	// Mapping of property values to schema names or references, defaults to the schema names.
+	Mapping map[string]string `json:"mapping"`
+}
+
 // AdditionalProperties handles additional properties present in the JSON schema.
 type AdditionalProperties Schema
 
@@ -20,6 +50,10 @@ type Schema struct {
 	ID04 string `json:"id"`  // up to draft-04
 	ID06 string `json:"$id"` // from draft-06 onwards
 
+	// Anchor names the schema within its document, referenced as a URI fragment, e.g. "#address".
+	// https://json-schema.org/draft/2020-12/json-schema-core#section-8.2.2
+	Anchor string `json:"$anchor"`
+
 	// Title and Description state the intent of the schema.
 	Title       string
 	Description string
@@ -33,10 +67,23 @@ type Schema struct {
 	EnumValue   []any       `json:"enum"`
 	Deprecated  bool        `json:"deprecated"`
 
+	// ReadOnly and WriteOnly values are only sent by, respectively to, the owner of the resource.
+	// https://json-schema.org/draft/2020-12/json-schema-validation#section-9.4
+	ReadOnly  bool `json:"readOnly"`
+	WriteOnly bool `json:"writeOnly"`
+
+	// ConstValue is the JSON encoding of the only valid value, nil when the keyword is absent.
+	// http://json-schema.org/draft-07/json-schema-validation.html#rfc.section.6.1.3
+	ConstValue json.RawMessage `json:"const"`
+
 	// Definitions are inline re-usable schemas.
-	// http://json-schema.org/draft-07/json-schema-validation.html#rfc.section.9
+	// https://json-schema.org/draft/2020-12/json-schema-core#section-8.2.4
 	Definitions map[string]*Schema `json:"$defs"`
 
+	// LegacyDefinitions are the inline re-usable schemas of draft-04 to draft-07.
+	// http://json-schema.org/draft-07/json-schema-validation.html#rfc.section.9
+	LegacyDefinitions map[string]*Schema `json:"definitions"`
+
 	// Properties, Required and AdditionalProperties describe an object's child instances.
 	// http://json-schema.org/draft-07/json-schema-validation.html#rfc.section.6.5
 	Properties map[string]*Schema
@@ -48,10 +95,64 @@ type Schema struct {
 	// "additionalProperties": false
 	AdditionalPropertiesBool *bool `json:"-"`
 
+	// PatternProperties apply to the properties with a name matching the regular expression key.
+	// http://json-schema.org/draft-07/json-schema-validation.html#rfc.section.6.5.5
+	PatternProperties map[string]*Schema `json:"patternProperties"`
+
+	// PropertyNames is the schema every property name is valid against.
+	// http://json-schema.org/draft-07/json-schema-validation.html#rfc.section.6.5.8
+	PropertyNames *Schema `json:"propertyNames"`
+
 	AnyOf []*Schema
 	AllOf []*Schema
 	OneOf []*Schema
 
+	// If, Then and Else apply Then to the instances valid against If, Else to the others.
+	// http://json-schema.org/draft-07/json-schema-validation.html#rfc.section.6.6
+	If   *Schema `json:"if"`
+	Then *Schema `json:"then"`
+	Else *Schema `json:"else"`
+
+	// OpenAPI is the version of an OpenAPI document, its "components/schemas" are read like "$defs".
+	// https://spec.openapis.org/oas/v3.1.0#openapi-object
+	OpenAPI           string      `json:"openapi"`
+	JSONSchemaDialect string      `json:"jsonSchemaDialect"`
+	Components        *Components `json:"components"`
+
+	// Nullable allows null in addition to the type (OpenAPI 3.0).
+	// https://spec.openapis.org/oas/v3.0.3#fixed-fields-19
+	Nullable bool `json:"nullable"`
+
+	// Discriminator names the property selecting the "oneOf" or "anyOf" schema (OpenAPI).
+	// https://spec.openapis.org/oas/v3.1.0#discriminator-object
+	Discriminator *Discriminator `json:"discriminator"`
+
+	// Validation keywords of strings.
+	// http://json-schema.org/draft-07/json-schema-validation.html#rfc.section.6.3
+	MinLength *int   `json:"minLength"`
+	MaxLength *int   `json:"maxLength"`
+	Pattern   string `json:"pattern"`
+
+	// Validation keywords of numbers. ExclusiveMinimumValue and ExclusiveMaximumValue are booleans modifying
+	// Minimum and Maximum up to draft-04, numbers from draft-06 onwards.
+	// http://json-schema.org/draft-07/json-schema-validation.html#rfc.section.6.2
+	Minimum               *float64    `json:"minimum"`
+	Maximum               *float64    `json:"maximum"`
+	ExclusiveMinimumValue interface{} `json:"exclusiveMinimum"`
+	ExclusiveMaximumValue interface{} `json:"exclusiveMaximum"`
+	MultipleOf            *float64    `json:"multipleOf"`
+
+	// Validation keywords of arrays.
+	// http://json-schema.org/draft-07/json-schema-validation.html#rfc.section.6.4
+	MinItems    *int `json:"minItems"`
+	MaxItems    *int `json:"maxItems"`
+	UniqueItems bool `json:"uniqueItems"`
+
+	// Validation keywords of objects.
+	// http://json-schema.org/draft-07/json-schema-validation.html#rfc.section.6.5
+	MinProperties *int `json:"minProperties"`
+	MaxProperties *int `json:"maxProperties"`
+
 	// Default can be used to supply a default JSON value associated with a particular schema.
 	// http://json-schema.org/draft-07/json-schema-validation.html#rfc.section.10.2
 	Default interface{}
@@ -66,7 +167,20 @@ type Schema struct {
 
 	// Items represents the types that are permitted in the array.
 	// http://json-schema.org/draft-07/json-schema-validation.html#rfc.section.6.4
-	Items *Schema
+	Items *Schema `json:"-"`
+
+	// PrefixItems are the positional schemas of a tuple, either "prefixItems" (2020-12) or "items": [...] (up to
+	// 2019-09).
+	// https://json-schema.org/draft/2020-12/json-schema-core#section-10.3.1.1
+	PrefixItems []*Schema `json:"-"`
+
+	// AdditionalItems applies to the array elements after the PrefixItems, "additionalItems" (up to 2019-09) or
+	// "items" next to "prefixItems" (2020-12).
+	AdditionalItems *AdditionalProperties `json:"-"`
+
+	// Contains requires at least one array element to be valid against the schema.
+	// https://json-schema.org/draft/2020-12/json-schema-core#section-10.3.1.3
+	Contains *Schema `json:"contains"`
 
 	// NameCount is the number of times the instance name was encountered across the schema.
 	NameCount int `json:"-" `
@@ -82,6 +196,23 @@ type Schema struct {
 
 	// calculated struct name of this object, cached here
 	GeneratedType string `json:"-"`
+
+	// Extensions are the vendor extension keywords starting with "x-", e.g. "x-go-type", with their JSON value.
+	Extensions map[string]json.RawMessage `json:"-"`
+
+	// keyOrder is the order of the keys in the document: of the schema object under "", of the "properties", "$defs",
+	// "definitions" and "components/schemas" objects under their keyword
+	keyOrder map[string][]string
+	// keywords the PrefixItems and the AdditionalItems were read from, see prefixItemsKeyword
+	tupleKeywords [2]string
+
+	// source of a root schema to locate errors: the file, the JSON document and the position of a document index in
+	// the file
+	file     string
+	document []byte
+	position func(index int) (line, character int, err error)
+	// uri of a root schema is the URI its document was read from, the base URI of its $id
+	uri string
 }
 
 // UnmarshalJSON handles unmarshalling AdditionalProperties from JSON.
@@ -116,6 +247,185 @@ func (ap *AdditionalProperties) UnmarshalJSON(data []byte) error {
 	return err
 }
 
+// UnmarshalJSON handles the different forms of the array keywords "items", "prefixItems" and "additionalItems".
+func (schema *Schema) UnmarshalJSON(data []byte) error {
+	type schemaAlias Schema
+	aux := &struct {
+		*schemaAlias
+		Items           json.RawMessage       `json:"items"`
+		PrefixItems     []*Schema             `json:"prefixItems"`
+		AdditionalItems *AdditionalProperties `json:"additionalItems"`
+	}{schemaAlias: (*schemaAlias)(schema)}
+	if err := json.Unmarshal(data, aux); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		var decodeErr *schemaDecodeError
+		if errors.As(err, &typeErr) && !errors.As(err, &decodeErr) {
+			// the offset is relative to data, remember it to find the position in the document
+			return &schemaDecodeError{data: data, err: typeErr}
+		}
+		return err
+	}
+
+	schema.PrefixItems = aux.PrefixItems
+	schema.AdditionalItems = aux.AdditionalItems
+	if len(aux.PrefixItems) > 0 {
+		schema.tupleKeywords[0] = "prefixItems"
+	}
+	if aux.AdditionalItems != nil {
+		schema.tupleKeywords[1] = "additionalItems"
+	}
+	schema.keyOrder, schema.Extensions = readKeys(data)
+
+	items := bytes.TrimSpace(aux.Items)
+	switch {
+	case len(items) == 0 || bytes.Equal(items, []byte("null")):
+		// no "items" keyword
+	case items[0] == '[':
+		// "items": [...] is the tuple form up to 2019-09
+		if err := json.Unmarshal(items, &schema.PrefixItems); err != nil {
+			return err
+		}
+		schema.tupleKeywords[0] = "items"
+	case len(schema.PrefixItems) > 0:
+		// "items" next to "prefixItems" describes the items after the tuple (2020-12)
+		ap := &AdditionalProperties{}
+		if err := json.Unmarshal(items, ap); err != nil {
+			return err
+		}
+		schema.AdditionalItems = ap
+		schema.tupleKeywords[1] = "items"
+	case items[0] == '{':
+		schema.Items = &Schema{}
+		if err := json.Unmarshal(items, schema.Items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readKeys returns the keys of the schema object in data, and of its objects of named schemas, in the order of the
+// document, along with the "x-" extension keywords. They are the keyOrder and the Extensions of a schema.
+func readKeys(data []byte) (order map[string][]string, extensions map[string]json.RawMessage) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil
+	}
+	order = make(map[string][]string)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return order, extensions
+		}
+		key, _ := tok.(string)
+		order[""] = append(order[""], key)
+		switch {
+		case key == "properties" || key == "$defs" || key == "definitions":
+			order[key] = readObjectKeys(dec, "")
+		case key == "components":
+			order["components/schemas"] = readObjectKeys(dec, "schemas")
+		default:
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return order, extensions
+			}
+			if strings.HasPrefix(key, "x-") {
+				if extensions == nil {
+					extensions = make(map[string]json.RawMessage)
+				}
+				extensions[key] = value
+			}
+		}
+	}
+	return order, extensions
+}
+
+// readObjectKeys reads the next value of dec and returns the keys of the object, or of the object under the key
+// nested when it's not empty.
+func readObjectKeys(dec *json.Decoder, nested string) []string {
+	var value json.RawMessage
+	if err := dec.Decode(&value); err != nil {
+		return nil
+	}
+	d := json.NewDecoder(bytes.NewReader(value))
+	if tok, err := d.Token(); err != nil || tok != json.Delim('{') {
+		return nil
+	}
+	var keys []string
+	for d.More() {
+		tok, err := d.Token()
+		if err != nil {
+			return keys
+		}
+		key, _ := tok.(string)
+		if nested != "" && key == nested {
+			return readObjectKeys(d, "")
+		}
+		if nested == "" {
+			keys = append(keys, key)
+		}
+		var skip json.RawMessage
+		if err := d.Decode(&skip); err != nil {
+			return keys
+		}
+	}
+	return keys
+}
+
+// PropertyKeys returns the keys of Properties in the order of the document. Keys without a known position, e.g. of
+// a schema built in Go, follow in alphabetical order.
+func (schema *Schema) PropertyKeys() []string {
+	return orderedKeys(schema.Properties, schema.keyOrder["properties"])
+}
+
+// definitionKeys returns the keys of the definitions of keyword, see definitionsByKeyword, in the order of the
+// document.
+func (schema *Schema) definitionKeys(keyword string) []string {
+	return orderedKeys(schema.definitionsByKeyword()[keyword], schema.keyOrder[keyword])
+}
+
+// orderedKeys returns the keys of m in order, followed by the others in alphabetical order.
+func orderedKeys(m map[string]*Schema, order []string) []string {
+	keys := make([]string, 0, len(m))
+	seen := make(map[string]bool, len(m))
+	for _, k := range order {
+		if _, ok := m[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	rest := make([]string, 0, len(m)-len(keys))
+	for k := range m {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// schemaDecodeError is a type error of a nested schema, the offset of the error is relative to data.
+type schemaDecodeError struct {
+	data []byte
+	err  *json.UnmarshalTypeError
+}
+
+func (e *schemaDecodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *schemaDecodeError) Unwrap() error {
+	return e.err
+}
+
+// documentError returns the type error with its offset in the document. The nested schema is a verbatim part of the
+// document; should the same text occur more than once, the first occurrence fails the same way.
+func (e *schemaDecodeError) documentError(document []byte) *json.UnmarshalTypeError {
+	if start := bytes.Index(document, e.data); start >= 0 {
+		e.err.Offset += int64(start)
+	}
+	return e.err
+}
+
 // ID returns the schema URI id.
 func (schema *Schema) ID() string {
 	// prefer "$id" over "id"
@@ -125,18 +435,207 @@ func (schema *Schema) ID() string {
 	return schema.ID06
 }
 
-// MultiType returns "type" as an array
+// baseURI returns the URI the references of schema resolve against: the $id of the closest schema declaring one,
+// resolved against the base URI of its parent, or the URI the document was read from.
+func (schema *Schema) baseURI() (*url.URL, error) {
+	var base *url.URL
+	if schema.IsRoot() {
+		u, err := url.Parse(schema.uri)
+		if err != nil {
+			return nil, err
+		}
+		base = u
+	} else {
+		u, err := schema.Parent.baseURI()
+		if err != nil {
+			return nil, err
+		}
+		base = u
+	}
+	id := schema.ID()
+	if id == "" || strings.HasPrefix(id, "#") {
+		// plain name fragments are anchors
+		base.Fragment = ""
+		return base, nil
+	}
+	ref, err := url.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	resolved := base.ResolveReference(ref)
+	resolved.Fragment = ""
+	return resolved, nil
+}
+
+// documentURI returns the URI of the document of the root schema, its $id when it wasn't read from one.
+func (root *Schema) documentURI() string {
+	if root.uri != "" {
+		return root.uri
+	}
+	return root.ID()
+}
+
+// Draft returns the specification version named by the "$schema" keyword of the root schema. OpenAPI 3.0 schemas
+// are a draft-04 dialect, OpenAPI 3.1 defaults to 2020-12.
+func (schema *Schema) Draft() Draft {
+	root := schema.GetRoot()
+	schemaType := root.SchemaType
+	if root.OpenAPI != "" && schemaType == "" {
+		if strings.HasPrefix(root.OpenAPI, "3.0") {
+			return Draft04
+		}
+		if root.JSONSchemaDialect == "" {
+			return Draft202012
+		}
+		schemaType = root.JSONSchemaDialect
+	}
+	switch {
+	case strings.Contains(schemaType, "draft-04"):
+		return Draft04
+	case strings.Contains(schemaType, "draft-06"):
+		return Draft06
+	case strings.Contains(schemaType, "draft-07"):
+		return Draft07
+	case strings.Contains(schemaType, "draft/2019-09"):
+		return Draft201909
+	case strings.Contains(schemaType, "draft/2020-12"):
+		return Draft202012
+	}
+	return DraftUnknown
+}
+
+// definitionsByKeyword returns the re-usable schemas keyed by the keyword holding them. Both keywords are accepted
+// regardless of the draft, so that schemas mixing them still resolve, see definitionsKeyword for the one of the draft.
+func (schema *Schema) definitionsByKeyword() map[string]map[string]*Schema {
+	rv := map[string]map[string]*Schema{
+		"$defs":       schema.Definitions,
+		"definitions": schema.LegacyDefinitions,
+	}
+	if schema.Components != nil {
+		rv["components/schemas"] = schema.Components.Schemas
+	}
+	return rv
+}
+
+// definitionsKeyword returns the keyword of the re-usable schema name: the one holding it, else the one of the draft,
+// "definitions" up to draft-07 and "$defs" from 2019-09 onwards, or "components/schemas" in OpenAPI documents.
+func (schema *Schema) definitionsKeyword(name string) string {
+	byKeyword := schema.definitionsByKeyword()
+	for _, keyword := range []string{"components/schemas", "$defs", "definitions"} {
+		if _, ok := byKeyword[keyword][name]; ok {
+			return keyword
+		}
+	}
+	switch d := schema.Draft(); {
+	case schema.GetRoot().Components != nil:
+		return "components/schemas"
+	case d != DraftUnknown && d < Draft201909:
+		return "definitions"
+	}
+	return "$defs"
+}
+
+// hasDefinitions returns true when any re-usable schemas are present.
+func (schema *Schema) hasDefinitions() bool {
+	for _, defs := range schema.definitionsByKeyword() {
+		if len(defs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// combinatorsByKeyword returns the subschemas of "allOf", "anyOf" and "oneOf" keyed by keyword.
+func (schema *Schema) combinatorsByKeyword() map[string][]*Schema {
+	return map[string][]*Schema{
+		"allOf": schema.AllOf,
+		"anyOf": schema.AnyOf,
+		"oneOf": schema.OneOf,
+	}
+}
+
+// conditionsByKeyword returns the "if", "then" and "else" schemas keyed by keyword.
+func (schema *Schema) conditionsByKeyword() map[string]*Schema {
+	rv := make(map[string]*Schema, 3)
+	for keyword, s := range map[string]*Schema{"if": schema.If, "then": schema.Then, "else": schema.Else} {
+		if s != nil {
+			rv[keyword] = s
+		}
+	}
+	return rv
+}
+
+// isDefinition returns true when the schema is a re-usable schema of its parent.
+func (schema *Schema) isDefinition() bool {
+	for keyword := range schema.definitionsByKeyword() {
+		if strings.HasPrefix(schema.PathElement, keyword+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// siblingsApply returns true when the keywords next to "$ref" apply too, from 2019-09 onwards. Up to draft-07 they
+// are ignored.
+func (schema *Schema) siblingsApply() bool {
+	d := schema.Draft()
+	return d == DraftUnknown || d >= Draft201909
+}
+
+// extendsReference returns true when the keywords next to "$ref" add properties to the referenced object or require
+// some of them, which makes a type of its own.
+func (schema *Schema) extendsReference() bool {
+	return schema.Reference != "" && schema.siblingsApply() &&
+		(len(schema.Properties) > 0 || len(schema.PatternProperties) > 0 || len(schema.Required) > 0)
+}
+
+// isAdditionalPropertiesMap returns true when the object is generated as the map of its additional properties rather
+// than as a struct: it has no other properties and it isn't a definition, which has to be a type.
+func (schema *Schema) isAdditionalPropertiesMap() bool {
+	return schema.AdditionalProperties != nil && schema.AdditionalProperties.AdditionalPropertiesBool == nil &&
+		len(schema.Properties) == 0 && len(schema.PatternProperties) == 0 && !schema.isDefinition()
+}
+
+// constString returns the "const" value when it's a string.
+func (schema *Schema) constString() (string, bool) {
+	var s string
+	if schema.ConstValue == nil || json.Unmarshal(schema.ConstValue, &s) != nil {
+		return "", false
+	}
+	return s, true
+}
+
+// prefixItemsKeyword returns the keyword the positional tuple items were read from. Schemas built in Go use the one
+// of the draft: "items": [...] up to 2019-09, "prefixItems" from 2020-12 onwards.
+func (schema *Schema) prefixItemsKeyword() string {
+	if schema.tupleKeywords[0] != "" {
+		return schema.tupleKeywords[0]
+	}
+	if d := schema.Draft(); d != DraftUnknown && d < Draft202012 {
+		return "items"
+	}
+	return "prefixItems"
+}
+
+// additionalItemsKeyword returns the keyword the items following the tuple were read from, see prefixItemsKeyword:
+// "additionalItems" up to 2019-09, "items" next to "prefixItems" from 2020-12 onwards.
+func (schema *Schema) additionalItemsKeyword() string {
+	if schema.tupleKeywords[1] != "" {
+		return schema.tupleKeywords[1]
+	}
+	if schema.prefixItemsKeyword() == "items" {
+		return "additionalItems"
+	}
+	return "items"
+}
+
+// MultiType returns "type" as an array without "null", pointer is set when null is allowed too.
 func (schema *Schema) MultiType() (types []string, isMultiType bool, pointer bool) {
-	if len(schema.EnumValue) > 0 {
+	if len(schema.EnumValue) > 0 || schema.ConstValue != nil {
 		return nil, false, false
 	}
 	// We've got a single value, e.g. { "type": "object" }
 	if ts, ok := schema.TypeValue.(string); ok {
-		if fv, ok := schema.FormatValue.(string); ok {
-			if fv == "date-time" {
-				return []string{"time"}, false, false
-			}
-		}
 		return []string{ts}, false, false
 	}
 
@@ -148,12 +647,6 @@ func (schema *Schema) MultiType() (types []string, isMultiType bool, pointer boo
 				optional = true
 			} else {
 				if s, ok := t.TypeValue.(string); ok {
-					if fv, ok := t.FormatValue.(string); ok {
-						if fv == "date-time" {
-							rv = append(rv, "time")
-							continue
-						}
-					}
 					rv = append(rv, s)
 				}
 			}
@@ -161,20 +654,64 @@ func (schema *Schema) MultiType() (types []string, isMultiType bool, pointer boo
 		return rv, len(rv) > 1, optional
 	}
 
-	// We could have multiple types in the type value, e.g. { "type": [ "object", "array" ] }
+	// We could have multiple types in the type value, e.g. { "type": [ "object", "array" ] }, null makes the other
+	// ones optional
 	if a, ok := schema.TypeValue.([]interface{}); ok {
 		rv := []string{}
+		optional := false
 		for _, n := range a {
 			if s, ok := n.(string); ok {
+				if s == "null" {
+					optional = true
+					continue
+				}
 				rv = append(rv, s)
 			}
 		}
-		return rv, len(rv) > 1, false
+		return rv, len(rv) > 1, optional
 	}
 
 	return nil, false, false
 }
 
+// ExclusiveMinimum returns the exclusive lower bound of a number, from either form of "exclusiveMinimum".
+func (schema *Schema) ExclusiveMinimum() *float64 {
+	return exclusiveBound(schema.ExclusiveMinimumValue, schema.Minimum)
+}
+
+// ExclusiveMaximum returns the exclusive upper bound of a number, from either form of "exclusiveMaximum".
+func (schema *Schema) ExclusiveMaximum() *float64 {
+	return exclusiveBound(schema.ExclusiveMaximumValue, schema.Maximum)
+}
+
+// InclusiveMinimum returns the inclusive lower bound of a number, nil if "minimum" is made exclusive (draft-04).
+func (schema *Schema) InclusiveMinimum() *float64 {
+	if b, ok := schema.ExclusiveMinimumValue.(bool); ok && b {
+		return nil
+	}
+	return schema.Minimum
+}
+
+// InclusiveMaximum returns the inclusive upper bound of a number, nil if "maximum" is made exclusive (draft-04).
+func (schema *Schema) InclusiveMaximum() *float64 {
+	if b, ok := schema.ExclusiveMaximumValue.(bool); ok && b {
+		return nil
+	}
+	return schema.Maximum
+}
+
+func exclusiveBound(exclusive interface{}, bound *float64) *float64 {
+	switch v := exclusive.(type) {
+	case bool:
+		if v {
+			return bound
+		}
+	case float64:
+		return &v
+	}
+	return nil
+}
+
 // GetRoot returns the root schema.
 func (schema *Schema) GetRoot() *Schema {
 	if schema.Parent != nil {
@@ -189,42 +726,93 @@ func Parse(schema string, uri *url.URL) (*Schema, error) {
 }
 
 // ParseWithSchemaKeyRequired parses a JSON schema from a string with a flag to set whether the schema key is required.
+// Problems of the schema are returned as a *SchemaError.
 func ParseWithSchemaKeyRequired(schema string, uri *url.URL, schemaKeyRequired bool) (*Schema, error) {
+	s, _, err := ParseWithOptions(schema, uri, ParseOptions{SchemaKeyRequired: schemaKeyRequired})
+	return s, err
+}
+
+// ParseWithOptions parses a JSON schema from a string and returns the diagnostics of the schema along with it. The
+// error joins the diagnostics that fail with the options, see Diagnostics.Err.
+func ParseWithOptions(schema string, uri *url.URL, opts ParseOptions) (*Schema, Diagnostics, error) {
+	document := []byte(schema)
+	position := func(index int) (int, int, error) {
+		return lineAndColumn(document, index)
+	}
+	return parse(document, uri, opts, filePath(uri), position)
+}
+
+// parse parses the JSON document of a schema read from file, position maps an index of document to the line and
+// character in the file.
+func parse(document []byte, uri *url.URL, opts ParseOptions, file string, position func(index int) (int, int, error)) (*Schema, Diagnostics, error) {
 	s := &Schema{}
-	err := json.Unmarshal([]byte(schema), s)
+	err := json.Unmarshal(document, s)
 
 	if err != nil {
-		return nil, err
+		e := &SchemaError{File: file, Err: err}
+		var offset int64
+		var syntaxError *json.SyntaxError
+		var decodeErr *schemaDecodeError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxError):
+			e.Message = "cannot parse JSON schema due to a syntax error"
+			offset = syntaxError.Offset
+		case errors.As(err, &decodeErr) || errors.As(err, &typeErr):
+			if decodeErr != nil {
+				typeErr = decodeErr.documentError(document)
+				e.Err = typeErr
+			}
+			e.Message = fmt.Sprintf("the JSON type '%v' cannot be converted into the Go '%v' type", typeErr.Value, typeErr.Type)
+			if fields := strings.Split(typeErr.Field, "."); typeErr.Field != "" {
+				e.Keyword = fields[len(fields)-1]
+			}
+			offset = typeErr.Offset
+		default:
+			e.Message = "cannot parse JSON schema"
+		}
+		if offset > 0 {
+			// the offsets of JSON errors are after the offending byte
+			if line, character, err := position(int(offset) - 1); err == nil {
+				e.Line, e.Column = line, character
+			}
+		}
+		return nil, nil, e
 	}
 
-	//if s.ID() == "" {
-	s.ID06 = uri.String()
-	//}
+	s.uri = uri.String()
+	s.file = file
+	s.document = document
+	s.position = position
 
-	if schemaKeyRequired && s.SchemaType == "" {
-		return nil, errors.New("JSON schema must have a $schema key unless schemaKeyRequired flag is set")
+	if opts.SchemaKeyRequired && s.SchemaType == "" && s.OpenAPI == "" {
+		return nil, nil, newSchemaError(s, "$schema", "JSON schema must have a $schema key unless schemaKeyRequired flag is set", nil)
 	}
 
 	// validate root URI, it MUST be an absolute URI
-	abs, err := url.Parse(s.ID())
+	abs, err := s.baseURI()
 	if err != nil {
-		return nil, errors.New("error parsing $id of document \"" + uri.String() + "\": " + err.Error())
+		return nil, nil, newSchemaError(s, "$id", "error parsing $id of document \""+uri.String()+"\"", err)
 	}
 	if !abs.IsAbs() {
-		return nil, errors.New("$id of document not absolute URI: \"" + uri.String() + "\": \"" + s.ID() + "\"")
+		return nil, nil, newSchemaError(s, "$id", "$id of document not absolute URI: \""+uri.String()+"\": \""+s.ID()+"\"", nil)
 	}
 
-	s.Init()
+	diagnostics := s.Init().merge(s.validateMetaSchema())
+	if err := diagnostics.Err(opts.Strict); err != nil {
+		return nil, diagnostics, err
+	}
 
-	return s, nil
+	return s, diagnostics, nil
 }
 
-// Init schema.
-func (schema *Schema) Init() {
+// Init links the schemas of the document and returns its problems, e.g. a $schema keyword below the root or a
+// duplicate $id. Warnings don't prevent generating types unless in strict mode, see Diagnostics.Err.
+func (schema *Schema) Init() Diagnostics {
 	root := schema.GetRoot()
 	root.updateParentLinks()
-	root.ensureSchemaKeyword()
 	root.updatePathElements()
+	return root.diagnose()
 }
 
 func (schema *Schema) updatePathElements() {
@@ -232,13 +820,20 @@ func (schema *Schema) updatePathElements() {
 		schema.PathElement = "#"
 	}
 
-	for k, d := range schema.Definitions {
-		d.PathElement = "$defs/" + k
-		d.updatePathElements()
+	for keyword, defs := range schema.definitionsByKeyword() {
+		for k, d := range defs {
+			d.PathElement = keyword + "/" + escapeJSONPointer(k)
+			d.updatePathElements()
+		}
 	}
 
 	for k, p := range schema.Properties {
-		p.PathElement = "properties/" + k
+		p.PathElement = "properties/" + escapeJSONPointer(k)
+		p.updatePathElements()
+	}
+
+	for k, p := range schema.PatternProperties {
+		p.PathElement = "patternProperties/" + escapeJSONPointer(k)
 		p.updatePathElements()
 	}
 
@@ -247,17 +842,51 @@ func (schema *Schema) updatePathElements() {
 		(*Schema)(schema.AdditionalProperties).updatePathElements()
 	}
 
+	if schema.PropertyNames != nil {
+		schema.PropertyNames.PathElement = "propertyNames"
+		schema.PropertyNames.updatePathElements()
+	}
+
 	if schema.Items != nil {
 		schema.Items.PathElement = "items"
 		schema.Items.updatePathElements()
 	}
+
+	for i, p := range schema.PrefixItems {
+		p.PathElement = schema.prefixItemsKeyword() + "/" + strconv.Itoa(i)
+		p.updatePathElements()
+	}
+
+	if schema.AdditionalItems != nil {
+		schema.AdditionalItems.PathElement = schema.additionalItemsKeyword()
+		(*Schema)(schema.AdditionalItems).updatePathElements()
+	}
+
+	if schema.Contains != nil {
+		schema.Contains.PathElement = "contains"
+		schema.Contains.updatePathElements()
+	}
+
+	for keyword, subSchemas := range schema.combinatorsByKeyword() {
+		for i, c := range subSchemas {
+			c.PathElement = keyword + "/" + strconv.Itoa(i)
+			c.updatePathElements()
+		}
+	}
+
+	for keyword, c := range schema.conditionsByKeyword() {
+		c.PathElement = keyword
+		c.updatePathElements()
+	}
 }
 
 func (schema *Schema) updateParentLinks() {
-	for k, d := range schema.Definitions {
-		d.JSONKey = k
-		d.Parent = schema
-		d.updateParentLinks()
+	for _, defs := range schema.definitionsByKeyword() {
+		for k, d := range defs {
+			d.JSONKey = k
+			d.Parent = schema
+			d.updateParentLinks()
+		}
 	}
 
 	for k, p := range schema.Properties {
@@ -265,60 +894,142 @@ func (schema *Schema) updateParentLinks() {
 		p.Parent = schema
 		p.updateParentLinks()
 	}
+	for _, p := range schema.PatternProperties {
+		p.Parent = schema
+		p.updateParentLinks()
+	}
 	if schema.AdditionalProperties != nil {
 		schema.AdditionalProperties.Parent = schema
 		(*Schema)(schema.AdditionalProperties).updateParentLinks()
 	}
+	if schema.PropertyNames != nil {
+		schema.PropertyNames.Parent = schema
+		schema.PropertyNames.updateParentLinks()
+	}
 	if schema.Items != nil {
 		schema.Items.Parent = schema
 		schema.Items.updateParentLinks()
 	}
+	for _, p := range schema.PrefixItems {
+		p.Parent = schema
+		p.updateParentLinks()
+	}
+	if schema.AdditionalItems != nil {
+		schema.AdditionalItems.Parent = schema
+		(*Schema)(schema.AdditionalItems).updateParentLinks()
+	}
+	if schema.Contains != nil {
+		schema.Contains.Parent = schema
+		schema.Contains.updateParentLinks()
+	}
+	for _, subSchemas := range schema.combinatorsByKeyword() {
+		for _, c := range subSchemas {
+			c.Parent = schema
+			c.updateParentLinks()
+		}
+	}
+	for _, c := range schema.conditionsByKeyword() {
+		c.Parent = schema
+		c.updateParentLinks()
+	}
 }
 
-func (schema *Schema) ensureSchemaKeyword() error {
-	check := func(k string, s *Schema) error {
-		if s.SchemaType != "" {
-			return errors.New("invalid $schema keyword: " + k)
-		}
-		return s.ensureSchemaKeyword()
+// subSchemas returns the direct sub-schemas of schema in the order of the document.
+func (schema *Schema) subSchemas() []*Schema {
+	type group struct {
+		// keywords the schemas may be read from
+		keywords []string
+		schemas  []*Schema
 	}
-	for k, d := range schema.Definitions {
-		if err := check(k, d); err != nil {
-			return err
+	var groups []group
+	add := func(schemas []*Schema, keywords ...string) {
+		if len(schemas) > 0 {
+			groups = append(groups, group{keywords: keywords, schemas: schemas})
 		}
 	}
-	for k, d := range schema.Properties {
-		if err := check(k, d); err != nil {
-			return err
+	named := func(m map[string]*Schema, keys []string) []*Schema {
+		rv := make([]*Schema, 0, len(keys))
+		for _, k := range keys {
+			rv = append(rv, m[k])
 		}
+		return rv
+	}
+
+	for keyword, defs := range schema.definitionsByKeyword() {
+		add(named(defs, schema.definitionKeys(keyword)), strings.Split(keyword, "/")[0])
 	}
+	add(named(schema.Properties, schema.PropertyKeys()), "properties")
+	add(named(schema.PatternProperties, orderedKeys(schema.PatternProperties, nil)), "patternProperties")
 	if schema.AdditionalProperties != nil {
-		if err := check("additionalProperties", (*Schema)(schema.AdditionalProperties)); err != nil {
-			return err
-		}
+		add([]*Schema{(*Schema)(schema.AdditionalProperties)}, "additionalProperties")
+	}
+	if schema.PropertyNames != nil {
+		add([]*Schema{schema.PropertyNames}, "propertyNames")
 	}
 	if schema.Items != nil {
-		if err := check("items", schema.Items); err != nil {
-			return err
+		add([]*Schema{schema.Items}, "items")
+	}
+	add(schema.PrefixItems, "prefixItems", "items")
+	if schema.AdditionalItems != nil {
+		add([]*Schema{(*Schema)(schema.AdditionalItems)}, "additionalItems", "items")
+	}
+	if schema.Contains != nil {
+		add([]*Schema{schema.Contains}, "contains")
+	}
+	for keyword, subSchemas := range schema.combinatorsByKeyword() {
+		add(subSchemas, keyword)
+	}
+	for keyword, c := range schema.conditionsByKeyword() {
+		add([]*Schema{c}, keyword)
+	}
+
+	// groups of keywords without a known position, e.g. of a schema built in Go, follow in alphabetical order
+	position := func(g group) int {
+		for i, k := range schema.keyOrder[""] {
+			if contains(g.keywords, k) {
+				return i
+			}
 		}
+		return len(schema.keyOrder[""])
 	}
-	return nil
+	sort.SliceStable(groups, func(i, j int) bool {
+		if pi, pj := position(groups[i]), position(groups[j]); pi != pj {
+			return pi < pj
+		}
+		return groups[i].keywords[0] < groups[j].keywords[0]
+	})
+	var rv []*Schema
+	for _, g := range groups {
+		rv = append(rv, g.schemas...)
+	}
+	return rv
 }
 
 // FixMissingTypeValue is backwards compatible, guessing the users intention when they didn't specify a type.
 func (schema *Schema) FixMissingTypeValue() {
 	if schema.TypeValue == nil {
-		if schema.Reference == "" && len(schema.Properties) > 0 {
+		if schema.Reference == "" && (len(schema.Properties) > 0 || len(schema.PatternProperties) > 0) {
 			schema.TypeValue = "object"
 			return
 		}
-		if schema.Items != nil {
+		if schema.Items != nil || len(schema.PrefixItems) > 0 {
 			schema.TypeValue = "array"
 			return
 		}
 	}
 }
 
+// isReferenceOnly returns true when the type of the schema is the one of its reference.
+func (schema *Schema) isReferenceOnly() bool {
+	if schema.Reference == "" || schema.ConstValue != nil || schema.extensionString(xGoType) != "" ||
+		schema.extendsReference() {
+		return false
+	}
+	schema.FixMissingTypeValue()
+	types, _, _ := schema.MultiType()
+	return len(types) == 0
+}
+
 // IsRoot returns true when the schema is the root.
 func (schema *Schema) IsRoot() bool {
 	return schema.Parent == nil
@@ -1,7 +1,12 @@
 package generate
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/url"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -98,3 +103,348 @@ func TestReturnedSchemaId(t *testing.T) {
 		}
 	}
 }
+
+func TestThatTupleItemsCanBeParsed(t *testing.T) {
+	tests := []struct {
+		name                string
+		input               string
+		expectedPrefixItems int
+		expectedItems       bool
+		expectedAdditional  bool
+	}{
+		{
+			name: "2020-12 prefixItems",
+			input: `{
+                "$schema": "https://json-schema.org/draft/2020-12/schema",
+                "type": "array",
+                "prefixItems": [ { "type": "number" }, { "type": "number" } ],
+                "items": false
+            }`,
+			expectedPrefixItems: 2,
+			expectedAdditional:  true,
+		},
+		{
+			name: "draft-04 items array",
+			input: `{
+                "$schema": "http://json-schema.org/draft-04/schema#",
+                "type": "array",
+                "items": [ { "type": "string" }, { "type": "integer" }, { "type": "boolean" } ]
+            }`,
+			expectedPrefixItems: 3,
+		},
+		{
+			name: "single items schema",
+			input: `{
+                "$schema": "http://json-schema.org/draft-07/schema#",
+                "type": "array",
+                "items": { "type": "string" },
+                "contains": { "type": "string" }
+            }`,
+			expectedItems: true,
+		},
+	}
+
+	for _, test := range tests {
+		so, err := Parse(test.input, &url.URL{Scheme: "file", Path: "jsonschemaparse_test.go"})
+		if err != nil {
+			t.Fatalf("%s: it was not possible to unmarshal the schema: %v", test.name, err)
+		}
+		if len(so.PrefixItems) != test.expectedPrefixItems {
+			t.Errorf("%s: expected %d prefix items, got %d", test.name, test.expectedPrefixItems, len(so.PrefixItems))
+		}
+		if (so.Items != nil) != test.expectedItems {
+			t.Errorf("%s: expected items to be set: %v", test.name, test.expectedItems)
+		}
+		if (so.AdditionalItems != nil) != test.expectedAdditional {
+			t.Errorf("%s: expected additional items to be set: %v", test.name, test.expectedAdditional)
+		}
+		for _, p := range so.PrefixItems {
+			if p.Parent != so {
+				t.Errorf("%s: expected the prefix items to be linked to their parent", test.name)
+			}
+		}
+	}
+}
+
+func TestThatTupleItemsAreResolvedInEveryDraft(t *testing.T) {
+	tests := []struct {
+		name       string
+		schemaType string
+		tuple      string
+		first      string
+		additional string
+	}{
+		{
+			name:       "draft-04 items array",
+			schemaType: "http://json-schema.org/draft-04/schema#",
+			tuple:      `"items": [{"type": "string"}], "additionalItems": {"type": "integer"}`,
+			first:      "#/items/0",
+			additional: "#/additionalItems",
+		},
+		{
+			name:       "2020-12 prefixItems",
+			schemaType: "https://json-schema.org/draft/2020-12/schema",
+			tuple:      `"prefixItems": [{"type": "string"}], "items": {"type": "integer"}`,
+			first:      "#/prefixItems/0",
+			additional: "#/items",
+		},
+		{
+			name:       "2019-09 items array",
+			schemaType: "https://json-schema.org/draft/2019-09/schema",
+			tuple:      `"items": [{"type": "string"}], "additionalItems": {"type": "integer"}`,
+			first:      "#/items/0",
+			additional: "#/additionalItems",
+		},
+		{
+			name:       "2020-12 with an items array",
+			schemaType: "https://json-schema.org/draft/2020-12/schema",
+			tuple:      `"items": [{"type": "string"}], "additionalItems": {"type": "integer"}`,
+			first:      "#/items/0",
+			additional: "#/additionalItems",
+		},
+		{
+			name:       "unknown draft with an items array",
+			schemaType: "http://json-schema.org/schema#",
+			tuple:      `"items": [{"type": "string"}], "additionalItems": {"type": "integer"}`,
+			first:      "#/items/0",
+			additional: "#/additionalItems",
+		},
+	}
+
+	for _, test := range tests {
+		doc := fmt.Sprintf(`{"$schema": %q, "type": "array", %s, "$defs": {"first": {"$ref": %q}, "rest": {"$ref": %q}}}`,
+			test.schemaType, test.tuple, test.first, test.additional)
+		so, err := Parse(doc, &url.URL{Scheme: "file", Path: "/tuple.json"})
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		r := NewRefResolver([]*Schema{so})
+		if err := r.Init(); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if first, err := r.GetSchemaByReference("", so.Definitions["first"]); err != nil || first != so.PrefixItems[0] {
+			t.Errorf("%s: expected %s to resolve to the first tuple item, got %v", test.name, test.first, err)
+		}
+		if rest, err := r.GetSchemaByReference("", so.Definitions["rest"]); err != nil || rest != (*Schema)(so.AdditionalItems) {
+			t.Errorf("%s: expected %s to resolve to the additional items, got %v", test.name, test.additional, err)
+		}
+		for pointer, expected := range map[string]*Schema{test.first[1:]: so.PrefixItems[0], test.additional[1:]: (*Schema)(so.AdditionalItems)} {
+			if s, ok := so.schemaAtPointer(pointer); !ok || s != expected {
+				t.Errorf("%s: expected %s to point to the tuple keyword", test.name, pointer)
+			}
+		}
+	}
+}
+
+func TestThatTheDraftIsDetected(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Draft
+	}{
+		{input: "http://json-schema.org/draft-04/schema#", expected: Draft04},
+		{input: "http://json-schema.org/draft-06/schema#", expected: Draft06},
+		{input: "http://json-schema.org/draft-07/schema#", expected: Draft07},
+		{input: "https://json-schema.org/draft/2019-09/schema", expected: Draft201909},
+		{input: "https://json-schema.org/draft/2020-12/schema", expected: Draft202012},
+		{input: "http://json-schema.org/schema#", expected: DraftUnknown},
+	}
+
+	for idx, test := range tests {
+		actual := (&Schema{SchemaType: test.input}).Draft()
+		if actual != test.expected {
+			t.Errorf("Test %d failed: For input \"%s\", expected %d, got %d", idx, test.input, test.expected, actual)
+		}
+	}
+}
+
+func TestThatLegacyDefinitionsCanBeParsed(t *testing.T) {
+	s := `{
+        "$schema": "http://json-schema.org/draft-07/schema#",
+        "title": "root",
+        "properties": {
+            "address": { "$ref": "#/definitions/address" }
+        },
+        "definitions": {
+            "address": { "type": "object" }
+        }
+    }`
+	so, err := Parse(s, &url.URL{Scheme: "file", Path: "/jsonschemaparse_test.go"})
+	if err != nil {
+		t.Fatal("It was not possible to unmarshal the schema:", err)
+	}
+
+	address, ok := so.LegacyDefinitions["address"]
+	if !ok {
+		t.Fatal("expected the address definition to be parsed")
+	}
+	if address.PathElement != "definitions/address" {
+		t.Errorf("expected the path element of the address definition to be definitions/address, but was %s", address.PathElement)
+	}
+
+	r := NewRefResolver([]*Schema{so})
+	if err := r.Init(); err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := r.GetSchemaByReference("", so.Properties["address"])
+	if err != nil {
+		t.Fatal("expected #/definitions/address to be resolved:", err)
+	}
+	if resolved != address {
+		t.Error("expected #/definitions/address to resolve to the address definition")
+	}
+}
+
+func TestThatFragmentsOfOtherDocumentsAreResolved(t *testing.T) {
+	order, err := Parse(`{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "properties": {
+            "relative": { "$ref": "../shared/common.json#/$defs/money" },
+            "rootPath": { "$ref": "/shared/common.json#/$defs/money" },
+            "escaped": { "$ref": "../shared/common.json#/$defs/a~1b~0c" },
+            "encoded": { "$ref": "../shared/common.json#/$defs/m%C3%B6nch" },
+            "deep": { "$ref": "../shared/common.json#/$defs/money/properties/currency" },
+            "anchor": { "$ref": "../shared/common.json#address" },
+            "document": { "$ref": "../shared/common.json" }
+        }
+    }`, &url.URL{Scheme: "file", Path: "/repo/types/order.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	common, err := Parse(`{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "$defs": {
+            "money": { "type": "object", "properties": { "currency": { "type": "string" } } },
+            "a/b~c": { "type": "object" },
+            "mönch": { "type": "object" },
+            "address": { "$anchor": "address", "type": "object" }
+        }
+    }`, &url.URL{Scheme: "file", Path: "/repo/shared/common.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRefResolver([]*Schema{order, common})
+	if err := r.Init(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		property string
+		expected *Schema
+	}{
+		{"relative", common.Definitions["money"]},
+		{"rootPath", common.Definitions["money"]},
+		{"escaped", common.Definitions["a/b~c"]},
+		{"encoded", common.Definitions["mönch"]},
+		{"deep", common.Definitions["money"].Properties["currency"]},
+		{"anchor", common.Definitions["address"]},
+		{"document", common},
+	}
+	for _, test := range tests {
+		resolved, err := r.GetSchemaByReference("/repo", order.Properties[test.property])
+		if err != nil {
+			t.Errorf("%s: %v", test.property, err)
+			continue
+		}
+		if resolved != test.expected {
+			t.Errorf("%s: expected %s to resolve to %s, got %s", test.property, order.Properties[test.property].Reference, test.expected.PathElement, resolved.PathElement)
+		}
+	}
+}
+
+func TestThatReferencesAreResolvedByID(t *testing.T) {
+	order, err := Parse(`{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "$id": "https://example.com/orders/order.json",
+        "properties": {
+            "absolute": { "$ref": "https://example.com/customer.json" },
+            "relative": { "$ref": "../customer.json#/$defs/address" },
+            "anchor": { "$ref": "/customer.json#address" },
+            "item": {
+                "$id": "item.json",
+                "properties": { "self": { "$ref": "item.json" } }
+            }
+        }
+    }`, &url.URL{Scheme: "file", Path: "/repo/b/deep/order.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	customer, err := Parse(`{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "$id": "https://example.com/customer.json",
+        "$defs": {
+            "address": { "$anchor": "address", "type": "object" }
+        }
+    }`, &url.URL{Scheme: "file", Path: "/repo/a/customer.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := order.ID(); id != "https://example.com/orders/order.json" {
+		t.Errorf("expected the declared $id, got %q", id)
+	}
+
+	r := NewRefResolver([]*Schema{order, customer})
+	if err := r.Init(); err != nil {
+		t.Fatal(err)
+	}
+	item := order.Properties["item"]
+	tests := []struct {
+		schema   *Schema
+		expected *Schema
+	}{
+		{order.Properties["absolute"], customer},
+		{order.Properties["relative"], customer.Definitions["address"]},
+		{order.Properties["anchor"], customer.Definitions["address"]},
+		{item.Properties["self"], item},
+	}
+	for _, test := range tests {
+		resolved, err := r.GetSchemaByReference("/repo", test.schema)
+		if err != nil {
+			t.Errorf("%s: %v", test.schema.Reference, err)
+			continue
+		}
+		if resolved != test.expected {
+			t.Errorf("expected %s to resolve to %s, got %s", test.schema.Reference, test.expected.PathElement, resolved.PathElement)
+		}
+	}
+}
+
+func TestThatNestedTypeErrorsHaveTheirDocumentOffset(t *testing.T) {
+	s := `{
+        "$schema": "http://json-schema.org/schema#",
+        "properties": {
+            "name": { "title": 5 }
+        }
+    }`
+	_, err := Parse(s, &url.URL{Scheme: "file", Path: "jsonschemaparse_test.go"})
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("expected a type error, got %v", err)
+	}
+	if expected := int64(strings.Index(s, "5 }") + 1); typeErr.Offset != expected {
+		t.Errorf("expected the error at offset %d, got %d", expected, typeErr.Offset)
+	}
+}
+
+func TestThatPropertiesKeepTheirOrder(t *testing.T) {
+	root, err := Parse(`{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "$defs": { "b": { "type": "string" }, "a": { "type": "string" } },
+    "properties": { "zeta": { "type": "string" }, "alpha": { "type": "string" }, "mid": { "type": "string" } }
+}`, &url.URL{Scheme: "file", Path: "/order.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := root.PropertyKeys(); !reflect.DeepEqual(keys, []string{"zeta", "alpha", "mid"}) {
+		t.Errorf("expected the properties in the document order, got %v", keys)
+	}
+	if keys := root.definitionKeys("$defs"); !reflect.DeepEqual(keys, []string{"b", "a"}) {
+		t.Errorf("expected the definitions in the document order, got %v", keys)
+	}
+
+	// properties added in Go follow alphabetically
+	root.Properties["beta"] = &Schema{}
+	if keys := root.PropertyKeys(); !reflect.DeepEqual(keys, []string{"zeta", "alpha", "mid", "beta"}) {
+		t.Errorf("expected the added property last, got %v", keys)
+	}
+}
@@ -0,0 +1,145 @@
+package generate
+
+import (
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Loader reads the schema documents. Documents are identified by URIs, which are also the base URIs of their
+// references, so that references resolve the same way whatever the documents are stored in.
+type Loader interface {
+	// Resolve returns the URI, without fragment, of the document referenced by ref from the document at base, or of
+	// the input file ref when base is "". ok is false when the loader can't read the document, e.g. a remote one.
+	Resolve(base, ref string) (uri string, ok bool)
+	// Load returns the content of the document at uri, a URI returned by Resolve.
+	Load(uri string) ([]byte, error)
+}
+
+// FileLoader reads the documents from the OS file system, their URIs are file URIs. References starting with "/" are
+// relative to RootPath, the other ones to the referencing file.
+type FileLoader struct {
+	RootPath string
+}
+
+// Resolve implements Loader.
+func (l FileLoader) Resolve(base, ref string) (string, bool) {
+	file := ref
+	if base != "" {
+		u, err := url.Parse(base)
+		if err != nil || u.Scheme != "file" {
+			return "", false
+		}
+		file = referencedFile(l.RootPath, filepath.FromSlash(u.Path), ref)
+		if file == "" {
+			return "", false
+		}
+	}
+	abPath, err := filepath.Abs(file)
+	if err != nil {
+		return "", false
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abPath)}).String(), true
+}
+
+// Load implements Loader.
+func (l FileLoader) Load(uri string) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.FromSlash(u.Path))
+}
+
+// FSLoader reads the documents from FS, e.g. an embed.FS or a zip.Reader. Their URIs are file URIs of their path in
+// FS, so references starting with "/" are relative to the root of FS.
+type FSLoader struct {
+	FS fs.FS
+}
+
+// Resolve implements Loader.
+func (l FSLoader) Resolve(base, ref string) (string, bool) {
+	if base == "" {
+		return (&url.URL{Scheme: "file", Path: path.Join("/", filepath.ToSlash(ref))}).String(), true
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", false
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+	u := b.ResolveReference(r)
+	if u.Scheme != "file" || u.Host != "" || !fs.ValidPath(strings.TrimPrefix(u.Path, "/")) {
+		return "", false
+	}
+	return (&url.URL{Scheme: "file", Path: u.Path}).String(), true
+}
+
+// Load implements Loader.
+func (l FSLoader) Load(uri string) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(l.FS, strings.TrimPrefix(u.Path, "/"))
+}
+
+// MapLoader reads the documents whose URI starts with a prefix of Prefixes from the directory the prefix is mapped
+// to, e.g. "https://schemas.example.com/" to "./schemas/", so that remote references resolve to local mirrors
+// offline. The directories are paths for Loader, which reads all the documents.
+type MapLoader struct {
+	Loader Loader
+	// k=URI prefix v=directory
+	Prefixes map[string]string
+}
+
+// Resolve implements Loader.
+func (l MapLoader) Resolve(base, ref string) (string, bool) {
+	uri := ref
+	if base != "" {
+		b, err := url.Parse(base)
+		if err != nil {
+			return "", false
+		}
+		r, err := url.Parse(ref)
+		if err != nil {
+			return "", false
+		}
+		u := b.ResolveReference(r)
+		u.Fragment = ""
+		uri = u.String()
+	}
+	// the longest prefix wins
+	var prefix string
+	for p := range l.Prefixes {
+		if strings.HasPrefix(uri, p) && len(p) > len(prefix) {
+			prefix = p
+		}
+	}
+	if prefix == "" {
+		return l.Loader.Resolve(base, ref)
+	}
+	rest, err := url.PathUnescape(strings.TrimPrefix(uri, prefix))
+	if err != nil {
+		return "", false
+	}
+	return l.Loader.Resolve("", path.Join(filepath.ToSlash(l.Prefixes[prefix]), rest))
+}
+
+// Load implements Loader.
+func (l MapLoader) Load(uri string) ([]byte, error) {
+	return l.Loader.Load(uri)
+}
+
+// documentPath returns the path of the document at uri in the messages, its file path for a file URI.
+func documentPath(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		return filepath.FromSlash(u.Path)
+	}
+	return uri
+}
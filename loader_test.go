@@ -0,0 +1,74 @@
+package generate
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestThatReferencesAreLoadedFromAnFSWithMirrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"order.json": {Data: []byte(`{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title": "Order",
+			"type": "object",
+			"properties": {
+				"address": {"$ref": "https://schemas.example.com/address.json"},
+				"items": {"type": "array", "items": {"$ref": "/common/item.json"}}
+			}
+		}`)},
+		"mirror/address.json": {Data: []byte(`{
+			"title": "Address",
+			"type": "object",
+			"properties": {"country": {"$ref": "country.json#/$defs/code"}}
+		}`)},
+		"mirror/country.json": {Data: []byte(`{"$defs": {"code": {"type": "string", "enum": ["FR", "US"]}}}`)},
+		"common/item.json":    {Data: []byte(`{"title": "Item", "type": "object", "properties": {"sku": {"type": "string"}}}`)},
+	}
+	loader := MapLoader{
+		Loader:   FSLoader{FS: fsys},
+		Prefixes: map[string]string{"https://schemas.example.com/": "mirror"},
+	}
+
+	files, err := AnalysisFilesWithLoader(loader, []string{"order.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uris []string
+	for _, file := range files {
+		uris = append(uris, file.URI)
+	}
+	expected := []string{"file:///order.json", "file:///mirror/address.json", "file:///common/item.json", "file:///mirror/country.json"}
+	if len(uris) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, uris)
+	}
+	for i := range expected {
+		if uris[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, uris)
+		}
+	}
+
+	schemas, _, err := ReadInputFilesWithOptions(files, ParseOptions{Loader: loader})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(schemas...)
+	g.Loader = loader
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal(err)
+	}
+	testField(g.Structs["Order"].Fields["Address"], "address", "Address", "*Address", false, t)
+	testField(g.Structs["Order"].Fields["Items"], "items", "Items", "[]*Item", false, t)
+	testField(g.Structs["Address"].Fields["Country"], "country", "Country", "*Code", false, t)
+}
+
+func TestThatFSLoaderStaysInTheFS(t *testing.T) {
+	loader := FSLoader{FS: fstest.MapFS{}}
+	for _, ref := range []string{"https://example.com/a.json", "//example.com/a.json"} {
+		if uri, ok := loader.Resolve("file:///schemas/a.json", ref); ok {
+			t.Errorf("expected %q not to be loaded, got %q", ref, uri)
+		}
+	}
+	if uri, ok := loader.Resolve("file:///schemas/a.json", "../../b.json#/$defs/c"); !ok || uri != "file:///b.json" {
+		t.Errorf("expected file:///b.json, got %q", uri)
+	}
+}
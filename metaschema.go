@@ -0,0 +1,493 @@
+package generate
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// metaSchemaFiles are the official meta-schemas, stored by the path of their URI.
+//
+//go:embed metaschema
+var metaSchemaFiles embed.FS
+
+// metaSchemaURIs are the meta-schemas of the drafts, the entry points of the validation.
+var metaSchemaURIs = map[Draft]string{
+	Draft04:     "http://json-schema.org/draft-04/schema",
+	Draft06:     "http://json-schema.org/draft-06/schema",
+	Draft07:     "http://json-schema.org/draft-07/schema",
+	Draft201909: "https://json-schema.org/draft/2019-09/schema",
+	Draft202012: "https://json-schema.org/draft/2020-12/schema",
+}
+
+// generatorKeywords are read by the generator although no meta-schema defines them.
+var generatorKeywords = []string{"nullable", "discriminator", "example"}
+
+var (
+	metaSchemasOnce sync.Once
+	// metaSchemas are the decoded meta-schemas by their URI without fragment.
+	metaSchemas map[string]interface{}
+	// metaSchemaKeywords are the keywords defined by any of the meta-schemas.
+	metaSchemaKeywords map[string]bool
+)
+
+func loadMetaSchemas() {
+	metaSchemas = make(map[string]interface{})
+	metaSchemaKeywords = make(map[string]bool)
+	for _, k := range generatorKeywords {
+		metaSchemaKeywords[k] = true
+	}
+	err := fs.WalkDir(metaSchemaFiles, "metaschema", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := metaSchemaFiles.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		doc, err := decodeJSONValue(b)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		object, _ := doc.(map[string]interface{})
+		id, _ := object["$id"].(string)
+		if id == "" {
+			id, _ = object["id"].(string)
+		}
+		metaSchemas[strings.TrimSuffix(id, "#")] = doc
+		properties, _ := object["properties"].(map[string]interface{})
+		for k := range properties {
+			metaSchemaKeywords[k] = true
+		}
+		return nil
+	})
+	if err != nil {
+		// the meta-schemas are embedded, they can't be missing or broken
+		panic(err)
+	}
+}
+
+// decodeJSONValue decodes a JSON document keeping the numbers exact.
+func decodeJSONValue(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	err := dec.Decode(&v)
+	return v, err
+}
+
+// metaProblem is a keyword of the validated document that doesn't match the meta-schema.
+type metaProblem struct {
+	// schema is the JSON pointer of the schema with the problem, location the JSON pointer of the offending value.
+	schema, location string
+	message          string
+	unknown          bool
+	typeMismatch     bool
+}
+
+// metaValidator validates a document against the meta-schema of a draft. Only the keywords used by the
+// meta-schemas are implemented.
+type metaValidator struct {
+	// dialect is the URI of the meta-schema, the target of the "#" recursive and "#meta" dynamic references.
+	dialect string
+}
+
+// validateMetaSchema returns the keywords of the root schema that don't match the meta-schema named by its $schema,
+// and the keywords no meta-schema defines. Documents without a known $schema, and OpenAPI documents, aren't checked.
+func (root *Schema) validateMetaSchema() Diagnostics {
+	dialect, ok := metaSchemaURIs[root.Draft()]
+	if !ok || root.OpenAPI != "" || root.document == nil {
+		return nil
+	}
+	metaSchemasOnce.Do(loadMetaSchemas)
+	instance, err := decodeJSONValue(root.document)
+	if err != nil {
+		return nil
+	}
+
+	v := &metaValidator{dialect: dialect}
+	var d Diagnostics
+	for _, p := range v.validate(metaSchemas[dialect], dialect, true, instance, "", "") {
+		d = append(d, root.metaDiagnostic(p))
+	}
+	return d
+}
+
+func (root *Schema) metaDiagnostic(p metaProblem) Diagnostic {
+	e := &SchemaError{File: root.file, Pointer: "#" + p.schema, Message: p.message}
+	if e.File == "" {
+		e.File = root.documentURI()
+	}
+	// the keyword is the first segment below the schema, the message locates deeper values
+	rest := strings.TrimPrefix(p.location, p.schema+"/")
+	keyword, below, _ := strings.Cut(rest, "/")
+	e.Keyword = strings.NewReplacer("~1", "/", "~0", "~").Replace(keyword)
+	if below != "" {
+		e.Message = "/" + below + ": " + e.Message
+	}
+	if offset, ok := pointerOffset(root.document, p.location); ok && root.position != nil {
+		if line, column, err := root.position(offset); err == nil {
+			e.Line, e.Column = line, column
+		}
+	}
+	// values of the wrong type are often the form of another draft, e.g. the boolean exclusiveMinimum of draft-04
+	// in a draft-07 document, which the generator reads anyway: they only fail in strict mode
+	severity := SeverityError
+	if p.unknown || p.typeMismatch {
+		severity = SeverityWarning
+	}
+	return Diagnostic{Severity: severity, SchemaError: e}
+}
+
+// validate returns the problems of instance, located at the JSON pointer location, against the meta-schema node
+// of the document base. isRoot tells that node is the root of its document, so instance is a schema.
+func (v *metaValidator) validate(node interface{}, base string, isRoot bool, instance interface{}, location, schema string) []metaProblem {
+	if isRoot {
+		schema = location
+	}
+	problem := func(message string) metaProblem {
+		return metaProblem{schema: schema, location: location, message: message}
+	}
+
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			return []metaProblem{problem("no value is allowed")}
+		}
+		return nil
+	case map[string]interface{}:
+	default:
+		return nil
+	}
+	n := node.(map[string]interface{})
+	var problems []metaProblem
+
+	if isRoot && base == v.dialect {
+		problems = append(problems, v.unknownKeywords(instance, location)...)
+	}
+
+	for _, keyword := range []string{"$ref", "$recursiveRef", "$dynamicRef"} {
+		ref, ok := n[keyword].(string)
+		if !ok {
+			continue
+		}
+		target, targetBase, targetIsRoot := v.resolve(base, keyword, ref)
+		problems = append(problems, v.validate(target, targetBase, targetIsRoot, instance, location, schema)...)
+	}
+
+	if t, ok := n["type"]; ok {
+		if !matchesType(instance, t) {
+			p := problem(fmt.Sprintf("expected %s, got %s", typeList(t), jsonType(instance)))
+			p.typeMismatch = true
+			// the other keywords don't apply to the wrong type
+			return append(problems, p)
+		}
+	}
+
+	if enum, ok := n["enum"].([]interface{}); ok && !containsValue(enum, instance) {
+		values := make([]string, 0, len(enum))
+		for _, e := range enum {
+			values = append(values, jsonString(e))
+		}
+		problems = append(problems, problem(fmt.Sprintf("%s is not one of %s", jsonString(instance), strings.Join(values, ", "))))
+	}
+
+	if number, ok := instance.(json.Number); ok {
+		value, _ := new(big.Float).SetString(number.String())
+		if minimum, ok := n["minimum"].(json.Number); ok && value != nil {
+			m, _ := new(big.Float).SetString(minimum.String())
+			exclusive, _ := n["exclusiveMinimum"].(bool)
+			if c := value.Cmp(m); exclusive && c <= 0 {
+				problems = append(problems, problem(fmt.Sprintf("%s must be greater than %s", number, minimum)))
+			} else if c < 0 {
+				problems = append(problems, problem(fmt.Sprintf("%s must be greater than or equal to %s", number, minimum)))
+			}
+		}
+		if minimum, ok := n["exclusiveMinimum"].(json.Number); ok && value != nil {
+			m, _ := new(big.Float).SetString(minimum.String())
+			if value.Cmp(m) <= 0 {
+				problems = append(problems, problem(fmt.Sprintf("%s must be greater than %s", number, minimum)))
+			}
+		}
+	}
+
+	if s, ok := instance.(string); ok {
+		if pattern, ok := n["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(s) {
+				problems = append(problems, problem(fmt.Sprintf("%q doesn't match the pattern %q", s, pattern)))
+			}
+		}
+	}
+
+	if items, ok := instance.([]interface{}); ok {
+		if minItems, ok := n["minItems"].(json.Number); ok {
+			if m, err := minItems.Int64(); err == nil && int64(len(items)) < m {
+				problems = append(problems, problem(fmt.Sprintf("expected at least %d items, got %d", m, len(items))))
+			}
+		}
+		if unique, _ := n["uniqueItems"].(bool); unique {
+			seen := make(map[string]bool)
+			for _, item := range items {
+				s := jsonString(item)
+				if seen[s] {
+					problems = append(problems, problem(fmt.Sprintf("the items must be unique, %s is repeated", s)))
+					break
+				}
+				seen[s] = true
+			}
+		}
+		if itemSchema, ok := n["items"]; ok {
+			for i, item := range items {
+				problems = append(problems, v.validate(itemSchema, base, false, item, fmt.Sprintf("%s/%d", location, i), schema)...)
+			}
+		}
+	}
+
+	if object, ok := instance.(map[string]interface{}); ok {
+		properties, _ := n["properties"].(map[string]interface{})
+		additional, hasAdditional := n["additionalProperties"]
+		for _, k := range sortedKeys(object) {
+			child := location + "/" + escapeJSONPointer(k)
+			if p, ok := properties[k]; ok {
+				problems = append(problems, v.validate(p, base, false, object[k], child, schema)...)
+			} else if hasAdditional {
+				problems = append(problems, v.validate(additional, base, false, object[k], child, schema)...)
+			}
+			if names, ok := n["propertyNames"]; ok {
+				for _, p := range v.validate(names, base, false, k, child, schema) {
+					p.message = fmt.Sprintf("property name %s", p.message)
+					problems = append(problems, p)
+				}
+			}
+		}
+		if dependencies, ok := n["dependencies"].(map[string]interface{}); ok {
+			for _, k := range sortedKeys(dependencies) {
+				required, _ := dependencies[k].([]interface{})
+				if _, ok := object[k]; !ok {
+					continue
+				}
+				for _, r := range required {
+					if name, _ := r.(string); name != "" {
+						if _, ok := object[name]; !ok {
+							problems = append(problems, metaProblem{schema: schema, location: location + "/" + escapeJSONPointer(k), message: fmt.Sprintf("requires %q", name)})
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if allOf, ok := n["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			problems = append(problems, v.validate(sub, base, false, instance, location, schema)...)
+		}
+	}
+	if anyOf, ok := n["anyOf"].([]interface{}); ok {
+		problems = append(problems, v.validateAnyOf(anyOf, base, instance, location, schema, problem)...)
+	}
+
+	return problems
+}
+
+// validateAnyOf returns nil when one of the alternatives matches. Otherwise it returns the problems of the only
+// alternative for the type of instance, as they explain best what's wrong.
+func (v *metaValidator) validateAnyOf(anyOf []interface{}, base string, instance interface{}, location, schema string, problem func(string) metaProblem) []metaProblem {
+	var candidates [][]metaProblem
+	for _, sub := range anyOf {
+		problems := v.validate(sub, base, false, instance, location, schema)
+		if len(problems) == 0 {
+			return nil
+		}
+		mismatch := false
+		for _, p := range problems {
+			mismatch = mismatch || p.typeMismatch && p.location == location
+		}
+		if !mismatch {
+			candidates = append(candidates, problems)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return []metaProblem{problem(fmt.Sprintf("%s doesn't match any of the allowed schemas", jsonString(instance)))}
+}
+
+// unknownKeywords returns the keywords of the schema instance that no meta-schema defines. Extensions starting with
+// "x-" are allowed.
+func (v *metaValidator) unknownKeywords(instance interface{}, location string) []metaProblem {
+	object, ok := instance.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var problems []metaProblem
+	for _, k := range sortedKeys(object) {
+		if metaSchemaKeywords[k] || strings.HasPrefix(k, "x-") {
+			continue
+		}
+		message := "unknown keyword"
+		if suggestion := closestKeyword(k); suggestion != "" {
+			message += fmt.Sprintf(", did you mean %q?", suggestion)
+		}
+		problems = append(problems, metaProblem{schema: location, location: location + "/" + escapeJSONPointer(k), message: message, unknown: true})
+	}
+	return problems
+}
+
+// resolve returns the meta-schema node referenced by ref from the document base, its document and whether the
+// node is the root of the document.
+func (v *metaValidator) resolve(base, keyword, ref string) (interface{}, string, bool) {
+	if keyword == "$recursiveRef" || keyword == "$dynamicRef" {
+		// the meta-schemas only refer dynamically to the dialect
+		return metaSchemas[v.dialect], v.dialect, true
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return true, base, false
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return true, base, false
+	}
+	target := b.ResolveReference(r)
+	fragment := target.Fragment
+	target.Fragment = ""
+	doc, ok := metaSchemas[target.String()]
+	if !ok {
+		return true, base, false
+	}
+	if fragment == "" {
+		return doc, target.String(), true
+	}
+	node := doc
+	for _, segment := range strings.Split(strings.TrimPrefix(fragment, "/"), "/") {
+		object, _ := node.(map[string]interface{})
+		node = object[strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)]
+	}
+	return node, target.String(), false
+}
+
+// closestKeyword returns the known keyword closest to k, or "" when none is close enough to be a typo.
+func closestKeyword(k string) string {
+	best, bestDistance := "", 3
+	for known := range metaSchemaKeywords {
+		if d := editDistance(strings.ToLower(k), strings.ToLower(known)); d < bestDistance || d == bestDistance && known < best {
+			best, bestDistance = known, d
+		}
+	}
+	if bestDistance > 2 || utf8.RuneCountInString(k) <= bestDistance {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// jsonType returns the JSON type of a decoded value.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if isInteger(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// isInteger tells whether n has no fractional part, 1.0 is an integer since draft-06.
+func isInteger(n json.Number) bool {
+	f, ok := new(big.Float).SetString(n.String())
+	return ok && f.IsInt()
+}
+
+func matchesType(instance interface{}, t interface{}) bool {
+	types, ok := t.([]interface{})
+	if !ok {
+		types = []interface{}{t}
+	}
+	actual := jsonType(instance)
+	for _, t := range types {
+		if t == actual || t == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func typeList(t interface{}) string {
+	types, ok := t.([]interface{})
+	if !ok {
+		return fmt.Sprint(t)
+	}
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		names = append(names, fmt.Sprint(t))
+	}
+	return strings.Join(names, " or ")
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	s := jsonString(v)
+	for _, value := range values {
+		if jsonString(value) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonString returns the JSON of a decoded value, objects have sorted keys so that equal values are equal strings.
+func jsonString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
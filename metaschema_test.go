@@ -0,0 +1,121 @@
+package generate
+
+import (
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestThatSchemasAreValidatedAgainstTheirMetaSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		severity Severity
+		line     int
+		pointer  string
+		keyword  string
+		message  string
+	}{
+		{
+			name:     "typo in draft-07",
+			schema:   "{\n  \"$schema\": \"http://json-schema.org/draft-07/schema#\",\n  \"type\": \"object\",\n  \"requried\": [\"a\"]\n}",
+			severity: SeverityWarning,
+			line:     4,
+			pointer:  "#",
+			keyword:  "requried",
+			message:  `unknown keyword, did you mean "required"?`,
+		},
+		{
+			name:     "draft-04 exclusiveMinimum in draft-07",
+			schema:   "{\n  \"$schema\": \"http://json-schema.org/draft-07/schema#\",\n  \"properties\": {\n    \"a\": { \"type\": \"number\", \"exclusiveMinimum\": true }\n  }\n}",
+			severity: SeverityWarning,
+			line:     4,
+			pointer:  "#/properties/a",
+			keyword:  "exclusiveMinimum",
+			message:  "expected number, got boolean",
+		},
+		{
+			name:     "negative minLength in draft-04",
+			schema:   "{\n  \"$schema\": \"http://json-schema.org/draft-04/schema#\",\n  \"items\": {\n    \"minLength\": -1\n  }\n}",
+			severity: SeverityError,
+			line:     4,
+			pointer:  "#/items",
+			keyword:  "minLength",
+			message:  "-1 must be greater than or equal to 0",
+		},
+		{
+			name:     "empty allOf in 2020-12",
+			schema:   "{\n  \"$schema\": \"https://json-schema.org/draft/2020-12/schema\",\n  \"$defs\": {\n    \"a\": { \"allOf\": [] }\n  }\n}",
+			severity: SeverityError,
+			line:     4,
+			pointer:  "#/$defs/a",
+			keyword:  "allOf",
+			message:  "expected at least 1 items, got 0",
+		},
+		{
+			name:     "invalid anchor in 2019-09",
+			schema:   "{\n  \"$schema\": \"https://json-schema.org/draft/2019-09/schema\",\n  \"properties\": {\n    \"a\": { \"$anchor\": \"1a\" }\n  }\n}",
+			severity: SeverityError,
+			line:     4,
+			pointer:  "#/properties/a",
+			keyword:  "$anchor",
+			message:  `"1a" doesn't match the pattern "^[A-Za-z][-A-Za-z0-9.:_]*$"`,
+		},
+	}
+
+	for _, test := range tests {
+		_, diagnostics, _ := ParseWithOptions(test.schema, &url.URL{Scheme: "file", Path: "/meta.json"}, ParseOptions{})
+		if len(diagnostics) != 1 {
+			t.Errorf("%s: expected a diagnostic, got %v", test.name, diagnostics)
+			continue
+		}
+		d := diagnostics[0]
+		if d.Severity != test.severity || d.Line != test.line || d.Pointer != test.pointer || d.Keyword != test.keyword || d.Message != test.message {
+			t.Errorf("%s: expected %v at line %d of %s %s: %s, got %v", test.name, test.severity, test.line, test.pointer, test.keyword, test.message, d)
+		}
+	}
+}
+
+func TestThatValidSchemasHaveNoMetaSchemaProblems(t *testing.T) {
+	schema := `{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "type": ["object", "null"],
+    "properties": {
+        "name": { "type": "string", "minLength": 1, "x-go-name": "Title" },
+        "tags": { "type": "array", "items": { "type": "string" }, "uniqueItems": true },
+        "price": { "type": "number", "exclusiveMinimum": 0 }
+    },
+    "required": ["name"],
+    "$defs": {
+        "id": { "type": "integer", "minimum": 1 }
+    }
+}`
+	_, diagnostics, err := ParseWithOptions(schema, &url.URL{Scheme: "file", Path: "/valid.json"}, ParseOptions{Strict: true})
+	if err != nil || len(diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v %v", diagnostics, err)
+	}
+}
+
+func TestThatTypeMismatchesOnlyFailInStrictMode(t *testing.T) {
+	for _, file := range []string{"test/example1.json", "test/example1a.json"} {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		uri := &url.URL{Scheme: "file", Path: "/" + file}
+		root, diagnostics, err := ParseWithOptions(string(b), uri, ParseOptions{})
+		if err != nil {
+			t.Fatalf("%s: expected the boolean exclusiveMinimum of draft-04 to be a warning, got %v", file, err)
+		}
+		if len(diagnostics) != 1 || diagnostics[0].Severity != SeverityWarning || diagnostics[0].Keyword != "exclusiveMinimum" {
+			t.Errorf("%s: expected a warning about exclusiveMinimum, got %v", file, diagnostics)
+		}
+		if err := New(root).CreateTypes("", "main", false); err != nil {
+			t.Errorf("%s: failed to create structs: %v", file, err)
+		}
+
+		if _, _, err := ParseWithOptions(string(b), uri, ParseOptions{Strict: true}); err == nil {
+			t.Errorf("%s: expected the type mismatch to fail in strict mode", file)
+		}
+	}
+}
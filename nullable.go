@@ -0,0 +1,61 @@
+package generate
+
+// isNullable reports whether null is a valid value besides the values of the other types of schema, with an OpenAPI
+// 3.0 nullable, a type array or a oneOf branch.
+func (schema *Schema) isNullable() bool {
+	_, _, null := schema.MultiType()
+	return schema.Nullable || null
+}
+
+const nullableHelper = `// Nullable is a value that can be absent, null or set, e.g. to tell the properties to remove from the ones to keep in a
+// patch. The zero value is absent, it isn't written by a field with omitempty.
+type Nullable[T any] map[bool]T
+
+// NewNullable returns a set Nullable.
+func NewNullable[T any](v T) Nullable[T] {
+	return Nullable[T]{true: v}
+}
+
+// NewNull returns a null Nullable.
+func NewNull[T any]() Nullable[T] {
+	var zero T
+	return Nullable[T]{false: zero}
+}
+
+// Get returns the value, ok is false when it's absent or null.
+func (n Nullable[T]) Get() (v T, ok bool) {
+	v, ok = n[true]
+	return v, ok
+}
+
+// IsNull reports whether the value is null.
+func (n Nullable[T]) IsNull() bool {
+	_, ok := n[false]
+	return ok
+}
+
+// IsSpecified reports whether the value is null or set, not absent.
+func (n Nullable[T]) IsSpecified() bool {
+	return len(n) != 0
+}
+
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if v, ok := n[true]; ok {
+		return json.Marshal(v)
+	}
+	return []byte("null"), nil
+}
+
+func (n *Nullable[T]) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*n = NewNull[T]()
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*n = NewNullable(v)
+	return nil
+}
+`
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"path"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -30,28 +33,123 @@ func getOrderedStructNames(m map[string]Struct) []string {
 	return keys
 }
 
+// orderedStructNames returns the keys of the structs in the order of the types.
+func orderedStructNames(m map[string]Struct, order Order) []string {
+	keys := getOrderedStructNames(m)
+	if order != OrderAlphabetical {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return m[keys[i]].SourceOrder < m[keys[j]].SourceOrder
+		})
+	}
+	return keys
+}
+
+// orderedFieldNames returns the keys of the fields of s in the order of the struct fields.
+func orderedFieldNames(s Struct, order Order) []string {
+	keys := getOrderedFieldNames(s.Fields)
+	if order != OrderAlphabetical {
+		sort.SliceStable(keys, func(i, j int) bool {
+			fi, fj := s.Fields[keys[i]], s.Fields[keys[j]]
+			// fields added ahead of the properties, like ObjectId, stay first
+			if order == OrderRequiredFirst && fi.Required != fj.Required && fi.SourceOrder >= 0 && fj.SourceOrder >= 0 {
+				return fi.Required
+			}
+			return fi.SourceOrder < fj.SourceOrder
+		})
+	}
+	return keys
+}
+
+// Order is the order of the generated types and struct fields.
+type Order int
+
+const (
+	// OrderAlphabetical sorts types and fields by name.
+	OrderAlphabetical Order = iota
+	// OrderSource keeps the order of the schemas in the documents.
+	OrderSource
+	// OrderRequiredFirst keeps the order of the documents, with the required fields ahead of the optional ones.
+	OrderRequiredFirst
+)
+
+// ParseOrder returns the order named "alphabetical", "source" or "required".
+func ParseOrder(name string) (Order, error) {
+	switch name {
+	case "alphabetical", "":
+		return OrderAlphabetical, nil
+	case "source":
+		return OrderSource, nil
+	case "required":
+		return OrderRequiredFirst, nil
+	}
+	return OrderAlphabetical, fmt.Errorf("unknown order %q, expected alphabetical, source or required", name)
+}
+
+// OutputOptions control the generated code.
+type OutputOptions struct {
+	// BSON adds bson tags and an ObjectId field to root structs.
+	BSON bool
+	// TagOmitempty leaves omitempty off the tags of optional fields.
+	TagOmitempty bool
+	// Validate emits a Validate() method per struct checking the validation keywords of the schema.
+	Validate bool
+	// Order of the types and of the struct fields, alphabetical by default.
+	Order Order
+}
+
 // Output generates code and writes to w.
 func Output(w io.Writer, g *Generator, pkg string, bson bool, tagOmitempty bool) {
-	structs := g.Structs
+	OutputWithOptions(w, g, pkg, OutputOptions{BSON: bson, TagOmitempty: tagOmitempty})
+}
+
+// OutputWithOptions generates code configured by opts and writes to w. With Generator.Packages, it's the code of the
+// types of the package of CreateTypes, see OutputPackage for the other ones.
+func OutputWithOptions(w io.Writer, g *Generator, pkg string, opts OutputOptions) {
+	outputPackage(w, g, "", pkg, opts)
+}
+
+// OutputPackage generates the code of the types of the package importPath, one of Generator.PackagePaths other than
+// "", and writes to w. The package is named after the last element of importPath, the types of the other packages
+// are qualified by their name and imported.
+func OutputPackage(w io.Writer, g *Generator, importPath string, opts OutputOptions) {
+	outputPackage(w, g, importPath, packageName(importPath), opts)
+}
+
+// outputPackage writes the code of the types of the package importPath named pkg.
+func outputPackage(out io.Writer, g *Generator, importPath, pkg string, opts OutputOptions) {
+	bson, tagOmitempty := opts.BSON, opts.TagOmitempty
+	view := g.packageView(importPath)
+	structs := view.structs
 	aliases := g.Aliases
+	var names []string
+	for _, k := range orderedStructNames(structs, opts.Order) {
+		if structs[k].Package == importPath {
+			names = append(names, k)
+		}
+	}
 
-	fmt.Fprintln(w, "// Code generated by schema-generate. DO NOT EDIT.")
-	fmt.Fprintln(w)
-	fmt.Fprintf(w, "package %v\n", cleanPackageName(pkg))
+	fmt.Fprintln(out, "// Code generated by schema-generate. DO NOT EDIT.")
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "package %v\n", cleanPackageName(pkg))
 
 	// write all the code into a buffer, compiler functions will return list of imports
 	// write list of imports into main output stream, followed by the code
 	codeBuf := new(bytes.Buffer)
 	imports := make(map[string]bool)
+	// k=import path v=package name, "" for the default
+	importNames := make(map[string]string, len(g.Imports))
+	for imp, name := range g.Imports {
+		importNames[imp] = name
+	}
 	if bson {
-		imports["go.mongodb.org/mongo-driver/bson/primitive"] = true
+		importNames["go.mongodb.org/mongo-driver/bson/primitive"] = ""
 	}
-	for _, v := range structs {
-		for _, f := range v.Fields {
-			if f.Type == "*time.Time" || f.Type == "time.Time" {
-				imports["time"] = true
-			}
+	for imp := range view.imports {
+		importNames[imp] = ""
+		if name := packageName(imp); name != path.Base(imp) {
+			importNames[imp] = name
 		}
+		imports[imp] = true
 	}
 
 	//for _, k := range getOrderedStructNames(structs) {
@@ -62,14 +160,29 @@ func Output(w io.Writer, g *Generator, pkg string, bson bool, tagOmitempty bool)
 	//	}
 	//}
 
-	if len(imports) > 0 {
-		fmt.Fprintf(w, "\nimport (\n")
-		for k := range imports {
-			fmt.Fprintf(w, "    \"%s\"\n", k)
+	for _, k := range names {
+		s := structs[k]
+		// properties matching a pattern are dispatched by the generated code
+		if len(s.PatternProperties) > 0 {
+			emitMarshalCode(codeBuf, s, imports, opts.Order)
+			emitUnmarshalCode(codeBuf, s, imports)
+		}
+		if len(s.TupleFields) > 0 {
+			emitTupleCode(codeBuf, s, imports)
+		}
+		if s.ConstType != "" {
+			emitConstCode(codeBuf, s, imports, bson)
+		}
+		if s.DiscriminatorProperty != "" {
+			emitDiscriminatorCode(codeBuf, s, imports)
+		}
+		if opts.Validate && hasViolations(s) {
+			emitValidateCode(codeBuf, s, structs, imports)
 		}
-		fmt.Fprintf(w, ")\n")
 	}
 
+	// the types, then the imports they need ahead of them
+	w := new(bytes.Buffer)
 	_ = aliases
 
 	//for _, k := range getOrderedFieldNames(aliases) {
@@ -80,7 +193,7 @@ func Output(w io.Writer, g *Generator, pkg string, bson bool, tagOmitempty bool)
 	//	fmt.Fprintf(w, "type %s %s\n", a.Name, a.Type)
 	//}
 
-	for _, k := range getOrderedStructNames(structs) {
+	for _, k := range names {
 		s := structs[k]
 
 		fmt.Fprintln(w, "")
@@ -98,6 +211,16 @@ func Output(w io.Writer, g *Generator, pkg string, bson bool, tagOmitempty bool)
 				}
 			}
 			fmt.Fprintln(w, ")")
+		} else if s.ConstType != "" {
+			fmt.Fprintf(w, "type %s %s\n", s.Name, s.ConstType)
+			fmt.Fprintln(w, "")
+			if s.ConstType == "struct{}" {
+				fmt.Fprintf(w, "// %sValue is the JSON encoding of the only valid %s.\n", s.Name, s.Name)
+				fmt.Fprintf(w, "const %sValue = %s\n", s.Name, strconv.Quote(s.ConstValue.(string)))
+			} else {
+				fmt.Fprintf(w, "// %sValue is the only valid %s.\n", s.Name, s.Name)
+				fmt.Fprintf(w, "const %sValue %s = %s\n", s.Name, s.Name, goLiteral(s.ConstValue))
+			}
 		} else if s.Func.Name != "" {
 			fmt.Fprintf(w, "type %s interface {\n", s.Name)
 
@@ -105,15 +228,26 @@ func Output(w io.Writer, g *Generator, pkg string, bson bool, tagOmitempty bool)
 
 			fmt.Fprintln(w, "}")
 
-			for _, val := range s.Func.NameTypes {
-				fmt.Fprintln(w, "")
-				fmt.Fprintf(w, "func (d *%s) %s() bool {\n", val, s.Func.Name)
-				fmt.Fprintf(w, "  return true\n")
-				fmt.Fprintln(w, "}")
+			emitImplementations(w, s)
+		} else if s.Type != "" {
+			fmt.Fprintf(w, "type %s %s\n", s.Name, s.Type)
+		} else if len(s.TupleFields) > 0 {
+			fmt.Fprintf(w, "type %s struct {\n", s.Name)
+			for _, fieldKey := range s.TupleFields {
+				f := s.Fields[fieldKey]
+				if f.Description != "" {
+					outputFieldDescriptionComment(f.Description, w)
+				}
+				fmt.Fprintf(w, "  %s %s\n", f.Name, f.Type)
+			}
+			if f, ok := s.Fields["AdditionalItems"]; ok {
+				outputFieldDescriptionComment("Items following the tuple positions", w)
+				fmt.Fprintf(w, "  %s %s\n", f.Name, f.Type)
 			}
+			fmt.Fprintln(w, "}")
 		} else {
 			fmt.Fprintf(w, "type %s struct {\n", s.Name)
-			for _, fieldKey := range getOrderedFieldNames(s.Fields) {
+			for _, fieldKey := range orderedFieldNames(s, opts.Order) {
 				f := s.Fields[fieldKey]
 				//link := "*"
 				//if f.Required {
@@ -121,32 +255,109 @@ func Output(w io.Writer, g *Generator, pkg string, bson bool, tagOmitempty bool)
 				//}
 				// Only apply omitempty if the field is not required.
 				omitempty := ",omitempty"
-				if tagOmitempty || f.Required {
+				if tagOmitempty || f.Required || f.JSONName == "-" {
+					omitempty = ""
+				}
+				// constants are always written, even when the field is the zero value
+				if structs[f.Type].ConstType != "" {
 					omitempty = ""
 				}
+				// x-omitempty decides per field
+				if f.Omitempty != nil && f.JSONName != "-" {
+					omitempty = ""
+					if *f.Omitempty {
+						omitempty = ",omitempty"
+					}
+				}
 				bsonTag := ""
 				if bson {
 					bsonTag = fmt.Sprintf(" bson:\"%s%s\"", f.JSONName, omitempty)
 				}
+				extraTags := ""
+				if f.Tags != "" {
+					extraTags = " " + f.Tags
+				}
 				if f.Description != "" {
 					outputFieldDescriptionComment(f.Description, w)
 				}
-				fmt.Fprintf(w, "  %s %s `json:\"%s%s\"%s`\n", f.Name, f.Type, f.JSONName, omitempty, bsonTag)
+				fmt.Fprintf(w, "  %s %s `json:\"%s%s\"%s%s`\n", f.Name, f.Type, f.JSONName, omitempty, bsonTag, extraTags)
 			}
 			fmt.Fprintln(w, "}")
 		}
 
 	}
 
+	// the types of the package implementing the interfaces of other packages
+	for _, k := range orderedStructNames(structs, opts.Order) {
+		if s := structs[k]; s.Func.Name != "" && s.Package != importPath {
+			emitImplementations(w, s)
+		}
+	}
+
+	// the types of the formats used, by the package when there are several
+	helpers := make([]string, 0, len(g.Helpers))
+	for name := range g.Helpers {
+		if len(g.Packages) == 0 || view.helpers[name] {
+			helpers = append(helpers, name)
+		}
+	}
+	sort.Strings(helpers)
+	for _, name := range helpers {
+		fmt.Fprintln(w, "")
+		fmt.Fprint(w, g.Helpers[name])
+	}
+
+	// the packages of the types, of the formats and of x-go-type, only the ones used when there are several packages
+	for imp, name := range importNames {
+		if name == "" {
+			name = path.Base(imp)
+		}
+		if len(g.Packages) == 0 || regexp.MustCompile(`\b`+regexp.QuoteMeta(name)+`\.`).Match(w.Bytes()) {
+			imports[imp] = true
+		}
+	}
+	if len(imports) > 0 {
+		paths := make([]string, 0, len(imports))
+		for k := range imports {
+			paths = append(paths, k)
+		}
+		sort.Strings(paths)
+		fmt.Fprintf(out, "\nimport (\n")
+		for _, k := range paths {
+			if name := importNames[k]; name != "" {
+				fmt.Fprintf(out, "    %s \"%s\"\n", name, k)
+				continue
+			}
+			fmt.Fprintf(out, "    \"%s\"\n", k)
+		}
+		fmt.Fprintf(out, ")\n")
+	}
+	out.Write(w.Bytes())
+
 	// write code after structs for clarity
-	w.Write(codeBuf.Bytes())
+	out.Write(codeBuf.Bytes())
+}
+
+// emitImplementations writes the methods of the types of the package implementing the interface s, the other ones
+// are qualified.
+func emitImplementations(w io.Writer, s Struct) {
+	for _, val := range s.Func.NameTypes {
+		if strings.Contains(val, ".") {
+			continue
+		}
+		fmt.Fprintln(w, "")
+		fmt.Fprintf(w, "func (d *%s) %s() bool {\n", val, s.Func.Name)
+		fmt.Fprintf(w, "  return true\n")
+		fmt.Fprintln(w, "}")
+	}
 }
 
-func emitMarshalCode(w io.Writer, s Struct, imports map[string]bool) {
+func emitMarshalCode(w io.Writer, s Struct, imports map[string]bool, order Order) {
 	imports["bytes"] = true
+	imports["encoding/json"] = true
 	fmt.Fprintf(w,
 		`
-func (strct *%s) MarshalJSON() ([]byte, error) {
+func (strct %s) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(make([]byte, 0))
 	buf.WriteString("{")
 `, s.Name)
@@ -154,7 +365,7 @@ func (strct *%s) MarshalJSON() ([]byte, error) {
 	if len(s.Fields) > 0 {
 		fmt.Fprintf(w, "    comma := false\n")
 		// Marshal all the defined fields
-		for _, fieldKey := range getOrderedFieldNames(s.Fields) {
+		for _, fieldKey := range orderedFieldNames(s, order) {
 			f := s.Fields[fieldKey]
 			if f.JSONName == "-" {
 				continue
@@ -171,6 +382,9 @@ func (strct *%s) MarshalJSON() ([]byte, error) {
 				} else {
 					fmt.Fprintf(w, "    // only required object types supported for marshal checking (for now)\n")
 				}
+			} else if isNillable(f.Type) {
+				// omit absent optional fields
+				fmt.Fprintf(w, "    if strct.%s != nil {\n", f.Name)
 			}
 
 			fmt.Fprintf(w,
@@ -186,23 +400,47 @@ func (strct *%s) MarshalJSON() ([]byte, error) {
 	}
 	comma = true
 `, f.JSONName, f.Name)
+			if !f.Required && isNillable(f.Type) {
+				fmt.Fprintf(w, "    }\n")
+			}
 		}
 	}
+	// Marshal the properties matching a pattern
+	for _, p := range s.PatternProperties {
+		emitMarshalMapCode(w, p.Field)
+	}
 	if s.AdditionalType != "" {
 		if s.AdditionalType != "false" {
-			imports["fmt"] = true
-
 			if len(s.Fields) == 0 {
 				fmt.Fprintf(w, "    comma := false\n")
 			}
 
 			fmt.Fprintf(w, "    // Marshal any additional Properties\n")
 			// Marshal any additional Properties
-			fmt.Fprintf(w, `    for k, v := range strct.AdditionalProperties {
+			emitMarshalMapCode(w, "AdditionalProperties")
+		}
+	}
+
+	fmt.Fprintf(w, `
+	buf.WriteString("}")
+	rv := buf.Bytes()
+	return rv, nil
+}
+`)
+}
+
+// emitMarshalMapCode writes the entries of the map field as properties.
+func emitMarshalMapCode(w io.Writer, field string) {
+	fmt.Fprintf(w, `    for k, v := range strct.%s {
 		if comma {
 			buf.WriteString(",")
 		}
-        buf.WriteString(fmt.Sprintf("\"%%s\":", k))
+		if tmp, err := json.Marshal(string(k)); err != nil {
+			return nil, err
+		} else {
+			buf.Write(tmp)
+		}
+		buf.WriteString(":")
 		if tmp, err := json.Marshal(v); err != nil {
 			return nil, err
 		} else {
@@ -210,16 +448,7 @@ func (strct *%s) MarshalJSON() ([]byte, error) {
 		}
         comma = true
 	}
-`)
-		}
-	}
-
-	fmt.Fprintf(w, `
-	buf.WriteString("}")
-	rv := buf.Bytes()
-	return rv, nil
-}
-`)
+`, field)
 }
 
 func emitUnmarshalCode(w io.Writer, s Struct, imports map[string]bool) {
@@ -232,7 +461,7 @@ func (strct *%s) UnmarshalJSON(b []byte) error {
 	for _, fieldKey := range getOrderedFieldNames(s.Fields) {
 		f := s.Fields[fieldKey]
 		if f.Required {
-			fmt.Fprintf(w, "    %sReceived := false\n", f.JSONName)
+			fmt.Fprintf(w, "    %sReceived := false\n", f.Name)
 		}
 	}
 	// setup initial unmarshal
@@ -264,30 +493,52 @@ func (strct *%s) UnmarshalJSON(b []byte) error {
              }
 `, f.JSONName, f.Name)
 		if f.Required {
-			fmt.Fprintf(w, "            %sReceived = true\n", f.JSONName)
+			fmt.Fprintf(w, "            %sReceived = true\n", f.Name)
 		}
 	}
 
+	keyType := s.KeyType
+	if keyType == "" {
+		keyType = "string"
+	}
+	if len(s.PatternProperties) > 0 || s.AdditionalType != "" {
+		fmt.Fprintf(w, "        default:\n")
+	}
+	// properties matching a pattern, the first matching pattern wins
+	for _, p := range s.PatternProperties {
+		imports["regexp"] = true
+		fmt.Fprintf(w, `            if %[1]s%[2]sPattern.MatchString(k) {
+                var value %[3]s
+                if err := json.Unmarshal([]byte(v), &value); err != nil {
+                    return err
+                }
+                if strct.%[2]s == nil {
+                    strct.%[2]s = make(map[%[4]s]%[3]s, 0)
+                }
+                strct.%[2]s[%[4]s(k)] = value
+                continue
+            }
+`, s.Name, p.Field, p.ValueType, keyType)
+	}
+
 	// handle additional property
 	if s.AdditionalType != "" {
 		if s.AdditionalType == "false" {
 			// all unknown properties are not allowed
 			imports["fmt"] = true
-			fmt.Fprintf(w, `        default:
-            return fmt.Errorf("additional property not allowed: \"" + k + "\"")
+			fmt.Fprintf(w, `            return fmt.Errorf("additional property not allowed: %%q", k)
 `)
 		} else {
-			fmt.Fprintf(w, `        default:
-            // an additional "%s" value
-            var additionalValue %s
+			fmt.Fprintf(w, `            // an additional "%[1]s" value
+            var additionalValue %[1]s
             if err := json.Unmarshal([]byte(v), &additionalValue); err != nil {
                 return err // invalid additionalProperty
             }
             if strct.AdditionalProperties == nil {
-                strct.AdditionalProperties = make(map[string]%s, 0)
+                strct.AdditionalProperties = make(map[%[2]s]%[1]s, 0)
             }
-            strct.AdditionalProperties[k]= additionalValue
-`, s.AdditionalType, s.AdditionalType, s.AdditionalType)
+            strct.AdditionalProperties[%[2]s(k)]= additionalValue
+`, s.AdditionalType, keyType)
 		}
 	}
 	fmt.Fprintf(w, "        }\n") // switch
@@ -302,12 +553,224 @@ func (strct *%s) UnmarshalJSON(b []byte) error {
     if !%sReceived {
         return errors.New("\"%s\" is required but was not present")
     }
-`, f.JSONName, f.JSONName, f.JSONName)
+`, f.JSONName, f.Name, f.JSONName)
 		}
 	}
 
 	fmt.Fprintf(w, "    return nil\n")
 	fmt.Fprintf(w, "}\n") // UnmarshalJSON
+
+	if len(s.PatternProperties) > 0 {
+		fmt.Fprintf(w, "\nvar (\n")
+		for _, p := range s.PatternProperties {
+			fmt.Fprintf(w, "\t%s%sPattern = regexp.MustCompile(%s)\n", s.Name, p.Field, strconv.Quote(p.Pattern))
+		}
+		fmt.Fprintf(w, ")\n")
+	}
+}
+
+func emitTupleCode(w io.Writer, s Struct, imports map[string]bool) {
+	imports["encoding/json"] = true
+	fmt.Fprintf(w, `
+// MarshalJSON encodes %[1]s as a JSON array.
+func (strct %[1]s) MarshalJSON() ([]byte, error) {
+	tuple := []interface{}{`, s.Name)
+	for i, fieldKey := range s.TupleFields {
+		if i > 0 {
+			fmt.Fprintf(w, ", ")
+		}
+		fmt.Fprintf(w, "strct.%s", fieldKey)
+	}
+	fmt.Fprintf(w, "}\n")
+	if s.TupleAdditionalType != "false" {
+		fmt.Fprintf(w, `	for _, v := range strct.AdditionalItems {
+		tuple = append(tuple, v)
+	}
+`)
+	}
+	fmt.Fprintf(w, `	return json.Marshal(tuple)
+}
+`)
+
+	fmt.Fprintf(w, `
+// UnmarshalJSON decodes %[1]s from a JSON array.
+func (strct *%[1]s) UnmarshalJSON(b []byte) error {
+	var tuple []json.RawMessage
+	if err := json.Unmarshal(b, &tuple); err != nil {
+		return err
+	}
+`, s.Name)
+	for i, fieldKey := range s.TupleFields {
+		fmt.Fprintf(w, `	if len(tuple) > %d {
+		if err := json.Unmarshal(tuple[%d], &strct.%s); err != nil {
+			return err
+		}
+	}
+`, i, i, fieldKey)
+	}
+	size := len(s.TupleFields)
+	if s.TupleAdditionalType == "false" {
+		imports["fmt"] = true
+		fmt.Fprintf(w, `	if len(tuple) > %[1]d {
+		return fmt.Errorf("%[2]s: expected at most %[1]d items but got %%d", len(tuple))
+	}
+`, size, s.Name)
+	} else {
+		fmt.Fprintf(w, `	if len(tuple) > %[1]d {
+		strct.AdditionalItems = make([]%[2]s, len(tuple)-%[1]d)
+		for i, v := range tuple[%[1]d:] {
+			if err := json.Unmarshal(v, &strct.AdditionalItems[i]); err != nil {
+				return err
+			}
+		}
+	}
+`, size, s.TupleAdditionalType)
+	}
+	fmt.Fprintf(w, "	return nil\n")
+	fmt.Fprintf(w, "}\n")
+}
+
+func emitConstCode(w io.Writer, s Struct, imports map[string]bool, bson bool) {
+	imports["encoding/json"] = true
+	imports["fmt"] = true
+	if s.ConstType == "struct{}" {
+		imports["reflect"] = true
+		fmt.Fprintf(w, `
+// MarshalJSON always encodes %[1]sValue.
+func (%[1]s) MarshalJSON() ([]byte, error) {
+	return []byte(%[1]sValue), nil
+}
+
+// UnmarshalJSON returns an error unless b equals %[1]sValue.
+func (strct *%[1]s) UnmarshalJSON(b []byte) error {
+	var v, expected interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(%[1]sValue), &expected); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(v, expected) {
+		return fmt.Errorf("%[1]s: expected %%s but got %%s", %[1]sValue, b)
+	}
+	return nil
+}
+`, s.Name)
+		if bson {
+			imports["go.mongodb.org/mongo-driver/bson"] = true
+			imports["go.mongodb.org/mongo-driver/bson/bsontype"] = true
+			fmt.Fprintf(w, `
+// MarshalBSONValue always encodes %[1]sValue.
+func (%[1]s) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(%[1]sValue), &v); err != nil {
+		return 0, nil, err
+	}
+	if v == nil {
+		return bsontype.Null, nil, nil
+	}
+	return bson.MarshalValue(v)
+}
+
+// UnmarshalBSONValue accepts any value, BSON documents can't be compared to %[1]sValue.
+func (strct *%[1]s) UnmarshalBSONValue(t bsontype.Type, b []byte) error {
+	return nil
+}
+`, s.Name)
+		}
+		return
+	}
+	verb := "%v"
+	if s.ConstType == "string" {
+		verb = "%q"
+	}
+	fmt.Fprintf(w, `
+// MarshalJSON always encodes %[1]sValue, even for the zero value.
+func (%[1]s) MarshalJSON() ([]byte, error) {
+	return json.Marshal(%[2]s(%[1]sValue))
+}
+
+// UnmarshalJSON returns an error unless b is %[1]sValue.
+func (strct *%[1]s) UnmarshalJSON(b []byte) error {
+	var v %[2]s
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if %[1]s(v) != %[1]sValue {
+		return fmt.Errorf("%[1]s: expected %[3]s but got %[3]s", %[1]sValue, v)
+	}
+	*strct = %[1]sValue
+	return nil
+}
+`, s.Name, s.ConstType, verb)
+	if bson {
+		imports["go.mongodb.org/mongo-driver/bson"] = true
+		imports["go.mongodb.org/mongo-driver/bson/bsontype"] = true
+		fmt.Fprintf(w, `
+// MarshalBSONValue always encodes %[1]sValue, even for the zero value.
+func (%[1]s) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(%[2]s(%[1]sValue))
+}
+
+// UnmarshalBSONValue returns an error unless the value is %[1]sValue.
+func (strct *%[1]s) UnmarshalBSONValue(t bsontype.Type, b []byte) error {
+	var v %[2]s
+	if err := bson.UnmarshalValue(t, b, &v); err != nil {
+		return err
+	}
+	if %[1]s(v) != %[1]sValue {
+		return fmt.Errorf("%[1]s: expected %[3]s but got %[3]s", %[1]sValue, v)
+	}
+	*strct = %[1]sValue
+	return nil
+}
+`, s.Name, s.ConstType, verb)
+	}
+}
+
+// goLiteral returns the golang literal of a string, bool, int or float64 value.
+func goLiteral(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func emitDiscriminatorCode(w io.Writer, s Struct, imports map[string]bool) {
+	imports["encoding/json"] = true
+	imports["fmt"] = true
+	fmt.Fprintf(w, `
+// Unmarshal%[1]s decodes the %[1]s implementation named by the "%[2]s" property.
+func Unmarshal%[1]s(b []byte) (%[1]s, error) {
+	var discriminator struct {
+		Value string `+"`json:\"%[2]s\"`"+`
+	}
+	if err := json.Unmarshal(b, &discriminator); err != nil {
+		return nil, err
+	}
+	switch discriminator.Value {
+`, s.Name, s.DiscriminatorProperty)
+	values := make([]string, 0, len(s.DiscriminatorMapping))
+	for k := range s.DiscriminatorMapping {
+		values = append(values, k)
+	}
+	sort.Strings(values)
+	for _, v := range values {
+		fmt.Fprintf(w, `	case %q:
+		v := &%s{}
+		if err := json.Unmarshal(b, v); err != nil {
+			return nil, err
+		}
+		return v, nil
+`, v, s.DiscriminatorMapping[v])
+	}
+	fmt.Fprintf(w, `	}
+	return nil, fmt.Errorf("%s: unknown %s %%q", discriminator.Value)
+}
+`, s.Name, s.DiscriminatorProperty)
 }
 
 func outputNameAndDescriptionComment(name, description string, w io.Writer) {
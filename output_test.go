@@ -1,7 +1,13 @@
 package generate
 
 import (
+	"bytes"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -35,6 +41,110 @@ func TestThatStructNamesAreOrdered(t *testing.T) {
 	}
 }
 
+func TestThatFieldNamesCanBeOrderedBySource(t *testing.T) {
+	s := Struct{Fields: map[string]Field{
+		"Zeta":     {SourceOrder: 1},
+		"Total":    {SourceOrder: 3, Required: true},
+		"Customer": {SourceOrder: 2},
+		"Id":       {SourceOrder: 4, Required: true},
+		"ObjectId": {SourceOrder: -1},
+	}}
+
+	tests := []struct {
+		order    Order
+		expected []string
+	}{
+		{OrderAlphabetical, []string{"Customer", "Id", "ObjectId", "Total", "Zeta"}},
+		{OrderSource, []string{"ObjectId", "Zeta", "Customer", "Total", "Id"}},
+		{OrderRequiredFirst, []string{"ObjectId", "Total", "Id", "Zeta", "Customer"}},
+	}
+	for _, test := range tests {
+		actual := orderedFieldNames(s, test.order)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("order %d: expected %s and actual %s should match in order", test.order, strings.Join(test.expected, ", "), strings.Join(actual, ", "))
+		}
+	}
+}
+
+func TestThatTypesAreOutputInSourceOrder(t *testing.T) {
+	root, err := Parse(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "title": "Order",
+    "type": "object",
+    "properties": {
+        "zeta": { "type": "string" },
+        "customer": { "$ref": "#/definitions/Customer" },
+        "id": { "type": "string" }
+    },
+    "definitions": {
+        "Customer": {
+            "type": "object",
+            "properties": {
+                "name": { "type": "string" },
+                "address": { "$ref": "#/definitions/Address" }
+            }
+        },
+        "Address": {
+            "type": "object",
+            "properties": {
+                "street": { "type": "string" },
+                "city": { "type": "string" }
+            }
+        }
+    }
+}`, &url.URL{Scheme: "file", Path: "/order.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(root)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	OutputWithOptions(&buf, g, "main", OutputOptions{Order: OrderSource})
+	code := buf.String()
+
+	expected := []string{"type Order struct", "Zeta", "Customer", "Id", "type Customer struct", "Name", "Address",
+		"type Address struct", "Street", "City"}
+	last := -1
+	for _, e := range expected {
+		i := strings.Index(code[last+1:], e)
+		if i < 0 {
+			t.Fatalf("expected %q after position %d in\n%s", e, last, code)
+		}
+		last += 1 + i
+	}
+}
+
+func TestThatGoExtensionsChangeTheTags(t *testing.T) {
+	yes, no := true, false
+	g := New()
+	g.Structs["Order"] = Struct{
+		Name: "Order",
+		Fields: map[string]Field{
+			"ID":    {Name: "ID", JSONName: "id", Type: "uuid.UUID", Required: true, Tags: `db:"id"`},
+			"Note":  {Name: "Note", JSONName: "note", Type: "*string", Omitempty: &no},
+			"Count": {Name: "Count", JSONName: "count", Type: "int", Required: true, Omitempty: &yes},
+		},
+	}
+	g.Imports["github.com/shopspring/decimal"] = "decimal"
+
+	var buf bytes.Buffer
+	OutputWithOptions(&buf, g, "main", OutputOptions{BSON: true})
+	code := buf.String()
+
+	for _, expected := range []string{
+		"ID uuid.UUID `json:\"id\" bson:\"id\" db:\"id\"`",
+		"Note *string `json:\"note\" bson:\"note\"`",
+		"Count int `json:\"count,omitempty\" bson:\"count,omitempty\"`",
+		`decimal "github.com/shopspring/decimal"`,
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("expected %s in\n%s", expected, code)
+		}
+	}
+}
+
 func TestLineAndCharacterFromOffset(t *testing.T) {
 	tests := []struct {
 		In                []byte
@@ -89,3 +199,332 @@ func TestLineAndCharacterFromOffset(t *testing.T) {
 		}
 	}
 }
+
+func TestThatValidateMethodsAreGenerated(t *testing.T) {
+	min := 1
+	structs := map[string]Struct{
+		"Order": {
+			Name: "Order",
+			Fields: map[string]Field{
+				"Customer": {Name: "Customer", JSONName: "customer", Type: "*Customer", Required: true},
+				"Lines":    {Name: "Lines", JSONName: "lines", Type: "[]string", Constraints: &Constraints{MinItems: &min}},
+			},
+		},
+		"Customer": {
+			Name: "Customer",
+			Fields: map[string]Field{
+				"Name": {Name: "Name", JSONName: "name", Type: "string", Constraints: &Constraints{Pattern: "^[a-z]+$"}},
+			},
+		},
+	}
+	imports := map[string]bool{}
+	buf := new(bytes.Buffer)
+	emitValidateCode(buf, structs["Order"], structs, imports)
+	emitValidateCode(buf, structs["Customer"], structs, imports)
+	code := buf.String()
+
+	for _, expected := range []string{
+		"func (strct *Order) Validate() error {",
+		`errs = append(errs, fmt.Errorf("%s: required property is missing", path + "/customer"))`,
+		`errs = append(errs, strct.Customer.Violations(path + "/customer")...)`,
+		"if strct.Lines != nil {\n\t\tif len(strct.Lines) < 1 {",
+		`Customer_NamePattern = regexp.MustCompile("^[a-z]+$")`,
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected the generated code to contain %q, got:\n%s", expected, code)
+		}
+	}
+	for _, pkg := range []string{"errors", "fmt", "regexp"} {
+		if !imports[pkg] {
+			t.Errorf("Expected the import %q, got %v", pkg, imports)
+		}
+	}
+}
+
+func TestThatPatternPropertiesAreDispatchedByKey(t *testing.T) {
+	s := Struct{
+		Name: "Catalog",
+		Fields: map[string]Field{
+			"Name":              {Name: "Name", JSONName: "name", Type: "string", Required: true},
+			"PatternProperties": {Name: "PatternProperties", JSONName: "-", Type: "map[Key]int"},
+		},
+		PatternProperties: []PatternProperty{{Pattern: "^n_", Field: "PatternProperties", ValueType: "int"}},
+		KeyType:           "Key",
+		AdditionalType:    "false",
+	}
+	imports := map[string]bool{}
+	buf := new(bytes.Buffer)
+	emitUnmarshalCode(buf, s, imports)
+	code := buf.String()
+
+	for _, expected := range []string{
+		"NameReceived := false",
+		"if CatalogPatternPropertiesPattern.MatchString(k) {",
+		"strct.PatternProperties[Key(k)] = value",
+		`CatalogPatternPropertiesPattern = regexp.MustCompile("^n_")`,
+		`return fmt.Errorf("additional property not allowed: %q", k)`,
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected the generated code to contain %q, got:\n%s", expected, code)
+		}
+	}
+	if !imports["regexp"] {
+		t.Errorf("Expected the regexp import, got %v", imports)
+	}
+}
+
+func TestThatConstFieldsAreAlwaysWritten(t *testing.T) {
+	g := &Generator{
+		Structs: map[string]Struct{
+			"Cancel": {
+				Name: "Cancel",
+				Fields: map[string]Field{
+					"EventType": {Name: "EventType", JSONName: "eventType", Type: "CancelEventType"},
+				},
+			},
+			"CancelEventType": {Name: "CancelEventType", ConstType: "string", ConstValue: "CancelTariffication"},
+		},
+	}
+	buf := new(bytes.Buffer)
+	Output(buf, g, "main", true, false)
+	code := buf.String()
+
+	for _, expected := range []string{
+		"EventType CancelEventType `json:\"eventType\" bson:\"eventType\"`",
+		`const CancelEventTypeValue CancelEventType = "CancelTariffication"`,
+		"func (CancelEventType) MarshalJSON() ([]byte, error) {",
+		"if CancelEventType(v) != CancelEventTypeValue {",
+		"func (CancelEventType) MarshalBSONValue() (bsontype.Type, []byte, error) {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected the generated code to contain %q, got:\n%s", expected, code)
+		}
+	}
+}
+
+func TestThatConditionalRequirementsAreValidated(t *testing.T) {
+	structs := map[string]Struct{
+		"Payment": {
+			Name: "Payment",
+			Fields: map[string]Field{
+				"Type":    {Name: "Type", JSONName: "type", Type: "*string"},
+				"Card":    {Name: "Card", JSONName: "card", Type: "*string"},
+				"Receipt": {Name: "Receipt", JSONName: "receipt", Type: "*string"},
+			},
+			Conditions: []Condition{{
+				Path: "#/if",
+				If:   []ConditionTest{{Property: "type", Values: []any{"card", "debit"}}},
+				Then: []string{"card"},
+				Else: []string{"receipt"},
+			}},
+		},
+	}
+	buf := new(bytes.Buffer)
+	emitValidateCode(buf, structs["Payment"], structs, map[string]bool{})
+	code := buf.String()
+
+	expected := `	// #/if
+	if (strct.Type == nil || *strct.Type == "card" || *strct.Type == "debit") {
+		if strct.Card == nil {
+			errs = append(errs, fmt.Errorf("%s: required property is missing", path + "/card"))
+		}
+	} else {
+		if strct.Receipt == nil {
+			errs = append(errs, fmt.Errorf("%s: required property is missing", path + "/receipt"))
+		}
+	}
+`
+	if !strings.Contains(code, expected) {
+		t.Errorf("Expected the generated code to contain:\n%s\ngot:\n%s", expected, code)
+	}
+}
+
+// runGenerated generates the code of the schema doc into a main package along with main, runs it and returns its
+// output. The test is skipped when there's no go command.
+func runGenerated(t *testing.T, doc, main string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("building the generated code is slow")
+	}
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go command isn't available:", err)
+	}
+	root, err := Parse(doc, &url.URL{Scheme: "file", Path: "/generated.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(root)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal(err)
+	}
+	code := new(bytes.Buffer)
+	OutputWithOptions(code, g, "main", OutputOptions{Validate: true})
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module example.com/generated\n\ngo 1.23\n",
+		"generated.go": code.String(),
+		"main.go":      main,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(goCmd, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to run the generated code: %v\n%s\n%s", err, out, code)
+	}
+	return string(out)
+}
+
+func TestThatGeneratedValidateMethodsRun(t *testing.T) {
+	doc := `{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "title": "Order",
+        "type": "object",
+        "properties": {
+            "price": { "type": "number", "multipleOf": 0.1 },
+            "barBaz": { "type": "string", "pattern": "^a" },
+            "bar": { "$ref": "#/$defs/OrderBar" },
+            "tags": { "type": "array", "items": { "type": "string", "pattern": "^t" }, "contains": { "pattern": "^tx" } },
+            "tagsItems": { "type": "string", "pattern": "^i" },
+            "roles": { "type": "array", "items": { "type": "string" }, "contains": { "const": "admin" } },
+            "levels": { "type": "array", "items": { "type": "integer" }, "contains": { "enum": [1, 2] } },
+            "kinds": { "type": "array", "items": { "enum": ["a", "b", "c"] }, "contains": { "const": "b" } },
+            "values": { "type": "array", "contains": { "enum": ["x", 1, null] } }
+        },
+        "$defs": {
+            "OrderBar": { "type": "object", "properties": { "baz": { "type": "string", "pattern": "^b" } } }
+        }
+    }`
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{input: `{"price": 0.3}`, valid: true},
+		{input: `{"price": 1.1}`, valid: true},
+		{input: `{"price": 0.35}`, valid: false},
+		{input: `{"barBaz": "a", "bar": {"baz": "b"}, "tagsItems": "i"}`, valid: true},
+		{input: `{"barBaz": "b"}`, valid: false},
+		{input: `{"bar": {"baz": "a"}}`, valid: false},
+		{input: `{"tags": ["ta", "txb"]}`, valid: true},
+		{input: `{"tags": ["ta"]}`, valid: false},
+		{input: `{"tags": ["txa", "x"]}`, valid: false},
+		{input: `{"roles": ["user", "admin"]}`, valid: true},
+		{input: `{"roles": ["user"]}`, valid: false},
+		{input: `{"levels": [3, 2]}`, valid: true},
+		{input: `{"levels": [3]}`, valid: false},
+		{input: `{"kinds": ["a", "b"]}`, valid: true},
+		{input: `{"kinds": ["a", "c"]}`, valid: false},
+		{input: `{"values": [true, 1]}`, valid: true},
+		{input: `{"values": [null]}`, valid: true},
+		{input: `{"values": ["y", 2]}`, valid: false},
+	}
+	var inputs []string
+	for _, test := range tests {
+		inputs = append(inputs, strconv.Quote(test.input))
+	}
+	main := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	for _, input := range []string{` + strings.Join(inputs, ", ") + `} {
+		var o Order
+		if err := json.Unmarshal([]byte(input), &o); err != nil {
+			panic(err)
+		}
+		fmt.Println(o.Validate() == nil)
+	}
+}
+`
+	lines := strings.Split(strings.TrimSpace(runGenerated(t, doc, main)), "\n")
+	if len(lines) != len(tests) {
+		t.Fatalf("expected a line per input, got %q", lines)
+	}
+	for i, test := range tests {
+		if lines[i] != strconv.FormatBool(test.valid) {
+			t.Errorf("%s: expected valid to be %v, got %s", test.input, test.valid, lines[i])
+		}
+	}
+}
+
+func TestThatPropertyNamesOutsideTheirEnumAreViolations(t *testing.T) {
+	doc := `{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "title": "Sales",
+        "type": "object",
+        "properties": {
+            "byRegion": { "type": "object", "propertyNames": { "enum": ["eu", "us"] }, "additionalProperties": { "type": "number" } },
+            "byCurrency": { "type": "object", "propertyNames": { "$ref": "#/$defs/Currency" }, "additionalProperties": { "type": "number" } }
+        },
+        "$defs": {
+            "Currency": { "enum": ["EUR", "USD"] }
+        }
+    }`
+	main := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	for _, input := range []string{` + "`" + `{"byRegion": {"eu": 1}, "byCurrency": {"USD": 2}}` + "`" + `, ` + "`" + `{"byRegion": {"xx": 1}, "byCurrency": {"GBP": 2}}` + "`" + `} {
+		var s Sales
+		if err := json.Unmarshal([]byte(input), &s); err != nil {
+			panic(err)
+		}
+		fmt.Println(s.Validate())
+	}
+}
+`
+	out := runGenerated(t, doc, main)
+	expected := "<nil>\n/byCurrency/GBP: must be one of \"EUR\", \"USD\"\n/byRegion/xx: must be one of \"eu\", \"us\"\n"
+	if out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+}
+
+func TestThatMissingRequiredConstantsAreViolations(t *testing.T) {
+	doc := `{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "title": "Event",
+        "type": "object",
+        "properties": {
+            "eventType": { "const": "Cancel" },
+            "price": { "type": "number" }
+        },
+        "required": ["eventType", "price"]
+    }`
+	main := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	for _, input := range []string{` + "`" + `{"eventType": "Cancel", "price": 1}` + "`" + `, ` + "`" + `{"price": 1}` + "`" + `} {
+		var e Event
+		if err := json.Unmarshal([]byte(input), &e); err != nil {
+			panic(err)
+		}
+		fmt.Println(e.Validate())
+	}
+}
+`
+	out := runGenerated(t, doc, main)
+	expected := "<nil>\n/eventType: required property is missing\n"
+	if out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+}
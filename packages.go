@@ -0,0 +1,206 @@
+package generate
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// packageOf returns the import path of the package of the types of schema, see Generator.Packages.
+func (g *Generator) packageOf(schema *Schema) string {
+	if len(g.Packages) == 0 {
+		return ""
+	}
+	uri := schema.GetRoot().documentURI()
+	// the longest prefix wins
+	prefix, found := "", false
+	for p := range g.Packages {
+		if (strings.HasPrefix(uri, p) || strings.HasPrefix(documentPath(uri), p)) && (!found || len(p) > len(prefix)) {
+			prefix, found = p, true
+		}
+	}
+	if !found {
+		return ""
+	}
+	return g.Packages[prefix]
+}
+
+// PackagePaths returns the sorted import paths of the packages of the types, "" for the package of CreateTypes.
+func (g *Generator) PackagePaths() []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, s := range g.Structs {
+		if !seen[s.Package] {
+			seen[s.Package] = true
+			paths = append(paths, s.Package)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// packageName returns the name of the package at importPath, its last element.
+func packageName(importPath string) string {
+	return cleanPackageName(path.Base(importPath))
+}
+
+// typeName matches the names of a Go type, e.g. "map", "string" and "time.Time" in "map[string]*time.Time".
+var typeName = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?`)
+
+// packageView is the types as they're written in the code of a package.
+type packageView struct {
+	// structs of the package keyed by name, and the ones of the other packages keyed by their qualified name
+	structs map[string]Struct
+	// imports of the package; k=import path v=a type of the package referred to
+	imports map[string]string
+	// helpers the types of the package refer to
+	helpers map[string]bool
+}
+
+// packageView returns the types as they're written in the code of the package importPath: the names of the types of
+// the other packages are qualified by their package name.
+func (g *Generator) packageView(importPath string) packageView {
+	v := packageView{
+		structs: make(map[string]Struct, len(g.Structs)),
+		imports: make(map[string]string),
+		helpers: make(map[string]bool),
+	}
+	for key, s := range g.Structs {
+		own := s.Package == importPath
+		qualify := func(typ string) string {
+			return typeName.ReplaceAllStringFunc(typ, func(name string) string {
+				if other, ok := g.Structs[name]; ok && other.Package != importPath {
+					if own {
+						v.imports[other.Package] = name
+					}
+					return packageName(other.Package) + "." + name
+				}
+				if _, ok := g.Helpers[name]; ok && own {
+					v.helpers[name] = true
+				}
+				return name
+			})
+		}
+		s = s.qualified(qualify)
+		if !own {
+			key = s.Name
+		}
+		v.structs[key] = s
+	}
+	return v
+}
+
+// qualified returns s with the types it refers to, and its name, written by qualify.
+func (s Struct) qualified(qualify func(typ string) string) Struct {
+	s.Name = qualify(s.Name)
+	if s.Fields != nil {
+		fields := make(map[string]Field, len(s.Fields))
+		for k, f := range s.Fields {
+			f.Type = qualify(f.Type)
+			fields[k] = f
+		}
+		s.Fields = fields
+	}
+	s.AdditionalType = qualify(s.AdditionalType)
+	s.KeyType = qualify(s.KeyType)
+	s.TupleAdditionalType = qualify(s.TupleAdditionalType)
+	s.Type = qualify(s.Type)
+	if s.PatternProperties != nil {
+		patterns := make([]PatternProperty, len(s.PatternProperties))
+		for i, p := range s.PatternProperties {
+			p.ValueType = qualify(p.ValueType)
+			patterns[i] = p
+		}
+		s.PatternProperties = patterns
+	}
+	if s.DiscriminatorMapping != nil {
+		mapping := make(map[string]string, len(s.DiscriminatorMapping))
+		for value, typ := range s.DiscriminatorMapping {
+			mapping[value] = qualify(typ)
+		}
+		s.DiscriminatorMapping = mapping
+	}
+	if s.Func.NameTypes != nil {
+		types := make([]string, len(s.Func.NameTypes))
+		for i, typ := range s.Func.NameTypes {
+			types[i] = qualify(typ)
+		}
+		s.Func.NameTypes = types
+	}
+	return s
+}
+
+// checkPackages returns an error when the types of a package of Packages refer to the ones of the package of
+// CreateTypes, which has no import path, when packages import each other or when they have the same name.
+func (g *Generator) checkPackages() error {
+	if len(g.Packages) == 0 {
+		return nil
+	}
+	imports := make(map[string][]string)
+	// k=import path v=the imports of the package and a type each refers to
+	referred := make(map[string]map[string]string)
+	// k=package name v=import path
+	names := make(map[string]string)
+	for _, p := range g.PackagePaths() {
+		if p != "" {
+			if other, ok := names[packageName(p)]; ok {
+				return g.typeError(g.firstTypeOf(p), "", fmt.Sprintf("the packages %s and %s have the same name", other, p))
+			}
+			names[packageName(p)] = p
+		}
+		view := g.packageView(p)
+		if typ, ok := view.imports[""]; ok && p != "" {
+			return g.typeError(typ, "", fmt.Sprintf("the types of the package %s refer to %s, which isn't in a package of Packages", p, typ))
+		}
+		referred[p] = view.imports
+		for imp := range view.imports {
+			imports[p] = append(imports[p], imp)
+		}
+		sort.Strings(imports[p])
+	}
+
+	// depth first, the packages being visited are on the chain
+	visited := make(map[string]bool)
+	var chain []string
+	var visit func(p string) error
+	visit = func(p string) error {
+		for i, q := range chain {
+			if q == p {
+				// located by the type of p the last package of the chain refers to
+				typ := referred[chain[len(chain)-1]][p]
+				return g.typeError(typ, "", "import cycle "+strings.Join(append(chain[i:], p), " -> "))
+			}
+		}
+		if visited[p] {
+			return nil
+		}
+		visited[p] = true
+		chain = append(chain, p)
+		for _, imp := range imports[p] {
+			if err := visit(imp); err != nil {
+				return err
+			}
+		}
+		chain = chain[:len(chain)-1]
+		return nil
+	}
+	for _, p := range g.PackagePaths() {
+		if err := visit(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// firstTypeOf returns the first type of the package importPath by name.
+func (g *Generator) firstTypeOf(importPath string) string {
+	var first string
+	for name, s := range g.Structs {
+		if s.Package == importPath && (first == "" || name < first) {
+			first = name
+		}
+	}
+	return first
+}
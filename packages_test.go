@@ -0,0 +1,146 @@
+package generate
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// parsePackageSchemas parses the documents keyed by their path.
+func parsePackageSchemas(t *testing.T, documents map[string]string) []*Schema {
+	var schemas []*Schema
+	for _, path := range []string{"/repo/cart.json", "/repo/orders/order.json", "/repo/common/money.json"} {
+		if documents[path] == "" {
+			continue
+		}
+		schema, err := Parse(documents[path], &url.URL{Scheme: "file", Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas
+}
+
+func TestThatReferencesToOtherPackagesAreQualified(t *testing.T) {
+	g := New(parsePackageSchemas(t, map[string]string{
+		"/repo/cart.json": `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title": "Cart",
+			"type": "object",
+			"properties": {"orders": {"type": "array", "items": {"$ref": "orders/order.json"}}}
+		}`,
+		"/repo/orders/order.json": `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title": "Order",
+			"type": "object",
+			"properties": {
+				"total": {"$ref": "../common/money.json"},
+				"prices": {"type": "object", "additionalProperties": {"$ref": "../common/money.json"}},
+				"currency": {"$ref": "../common/money.json#/$defs/currency"}
+			}
+		}`,
+		"/repo/common/money.json": `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title": "Money",
+			"type": "object",
+			"properties": {"amount": {"type": "number"}, "currency": {"$ref": "#/$defs/currency"}},
+			"$defs": {"currency": {"type": "string", "enum": ["EUR", "USD"]}}
+		}`,
+	})...)
+	g.Packages = map[string]string{
+		"/repo/orders/":            "example.com/api/orders",
+		"file:///repo/common/":     "example.com/api/common",
+		"/repo/common/unused.json": "example.com/api/unused",
+	}
+	if err := g.CreateTypes("/repo", "main", false); err != nil {
+		t.Fatal(err)
+	}
+	if paths := g.PackagePaths(); strings.Join(paths, " ") != " example.com/api/common example.com/api/orders" {
+		t.Errorf("expected the default, common and orders packages, got %q", paths)
+	}
+	if p := g.Structs["Currency"].Package; p != "example.com/api/common" {
+		t.Errorf("expected the currency in the common package, got %q", p)
+	}
+
+	tests := []struct {
+		importPath string
+		expected   []string
+		unexpected []string
+	}{
+		{
+			importPath: "",
+			expected:   []string{"package main", `"example.com/api/orders"`, "Orders []*orders.Order"},
+			unexpected: []string{"type Order ", "example.com/api/common"},
+		},
+		{
+			importPath: "example.com/api/orders",
+			expected: []string{
+				"package orders", `"example.com/api/common"`, "type Order struct",
+				"Total *common.Money", "Prices map[string]common.Money", "Currency *common.Currency",
+			},
+			unexpected: []string{"type Money ", "type Cart "},
+		},
+		{
+			importPath: "example.com/api/common",
+			expected:   []string{"package common", "type Money struct", "Currency *Currency", "type Currency string"},
+			unexpected: []string{"import", "common."},
+		},
+	}
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		if test.importPath == "" {
+			OutputWithOptions(buf, g, "main", OutputOptions{})
+		} else {
+			OutputPackage(buf, g, test.importPath, OutputOptions{})
+		}
+		code := buf.String()
+		for _, s := range test.expected {
+			if !strings.Contains(code, s) {
+				t.Errorf("%q: expected %q in:\n%s", test.importPath, s, code)
+			}
+		}
+		for _, s := range test.unexpected {
+			if strings.Contains(code, s) {
+				t.Errorf("%q: unexpected %q in:\n%s", test.importPath, s, code)
+			}
+		}
+	}
+}
+
+func TestThatPackagesCantImportEachOther(t *testing.T) {
+	documents := map[string]string{
+		"/repo/orders/order.json": `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title": "Order",
+			"type": "object",
+			"properties": {"total": {"$ref": "../common/money.json"}}
+		}`,
+		"/repo/common/money.json": `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title": "Money",
+			"type": "object",
+			"properties": {"order": {"$ref": "../orders/order.json"}}
+		}`,
+	}
+	g := New(parsePackageSchemas(t, documents)...)
+	g.Packages = map[string]string{"/repo/orders/": "example.com/api/orders", "/repo/common/": "example.com/api/common"}
+	err := g.CreateTypes("/repo", "main", false)
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.Message != "import cycle example.com/api/common -> example.com/api/orders -> example.com/api/common" {
+		t.Fatalf("expected an import cycle, got %v", err)
+	}
+	// located by the type of the package closing the cycle
+	if schemaErr.File != "/repo/common/money.json" || schemaErr.Pointer != "#" {
+		t.Errorf("expected the import cycle at /repo/common/money.json#, got %s%s", schemaErr.File, schemaErr.Pointer)
+	}
+
+	g = New(parsePackageSchemas(t, documents)...)
+	g.Packages = map[string]string{"/repo/common/": "example.com/api/common"}
+	err = g.CreateTypes("/repo", "main", false)
+	if !errors.As(err, &schemaErr) || !strings.Contains(schemaErr.Message, "refer to Order, which isn't in a package of Packages") {
+		t.Errorf("expected the reference to the default package to fail, got %v", err)
+	}
+}
@@ -0,0 +1,460 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ReflectOptions selects the Go types Reflect writes a JSON schema for.
+type ReflectOptions struct {
+	// Dir is the directory the package patterns are relative to, the current directory when empty.
+	Dir string
+	// Types are the names of the types to reflect, all the exported types of the packages when empty.
+	Types []string
+}
+
+// Reflect loads the Go packages matching patterns, e.g. "./model", and returns a 2020-12 JSON schema with a $defs
+// entry per selected struct and per struct or enum they use, so that a Generator generates them back. The schema
+// ends with a newline.
+//
+// Struct fields are properties named by their json tag, or their bson tag, and described by their doc comments.
+// Fields that are neither pointers nor omitempty are required. A type with typed constants is an enum of their values.
+func Reflect(opts ReflectOptions, patterns ...string) ([]byte, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+		Dir:  opts.Dir,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the packages %v: %w", patterns, err)
+	}
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, fmt.Errorf("failed to load the package %s: %w", pkg.PkgPath, pkg.Errors[0])
+		}
+	}
+
+	r := newReflector(pkgs)
+	selected, err := r.selectTypes(pkgs, opts.Types)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range selected {
+		if _, err := r.reference(obj.Type().(*types.Named)); err != nil {
+			return nil, err
+		}
+	}
+
+	root := newOrderedObject()
+	root.set("$schema", metaSchemaURIs[Draft202012])
+	root.set("$defs", r.defs)
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// reflector converts Go types to JSON schemas.
+type reflector struct {
+	// doc comments of the types and fields; k=position of the name
+	docs map[token.Pos]string
+	// typed constants in the order of their declaration
+	consts map[*types.TypeName][]*types.Const
+	// names of the types in $defs
+	names map[*types.TypeName]string
+	defs  *orderedObject
+}
+
+func newReflector(pkgs []*packages.Package) *reflector {
+	r := &reflector{
+		docs:   make(map[token.Pos]string),
+		consts: make(map[*types.TypeName][]*types.Const),
+		names:  make(map[*types.TypeName]string),
+		defs:   newOrderedObject(),
+	}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.GenDecl:
+					r.readDeclaration(pkg, n)
+				case *ast.StructType:
+					for _, field := range n.Fields.List {
+						doc := field.Doc
+						if doc == nil {
+							doc = field.Comment
+						}
+						for _, name := range field.Names {
+							r.docs[name.Pos()] = strings.TrimSpace(doc.Text())
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+	return r
+}
+
+// readDeclaration records the doc comments of the types and the constants declared by decl.
+func (r *reflector) readDeclaration(pkg *packages.Package, decl *ast.GenDecl) {
+	for _, spec := range decl.Specs {
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			doc := spec.Doc
+			if doc == nil && len(decl.Specs) == 1 {
+				doc = decl.Doc
+			}
+			r.docs[spec.Name.Pos()] = strings.TrimSpace(doc.Text())
+		case *ast.ValueSpec:
+			for _, name := range spec.Names {
+				c, ok := pkg.TypesInfo.Defs[name].(*types.Const)
+				if !ok {
+					continue
+				}
+				if named, ok := c.Type().(*types.Named); ok {
+					r.consts[named.Obj()] = append(r.consts[named.Obj()], c)
+				}
+			}
+		}
+	}
+}
+
+// selectTypes returns the types named by names, or the exported types declared by pkgs when there are none.
+func (r *reflector) selectTypes(pkgs []*packages.Package, names []string) ([]*types.TypeName, error) {
+	var selected []*types.TypeName
+	if len(names) == 0 {
+		for _, pkg := range pkgs {
+			for _, file := range pkg.Syntax {
+				for _, decl := range file.Decls {
+					decl, ok := decl.(*ast.GenDecl)
+					if !ok || decl.Tok != token.TYPE {
+						continue
+					}
+					for _, spec := range decl.Specs {
+						spec := spec.(*ast.TypeSpec)
+						obj, ok := pkg.TypesInfo.Defs[spec.Name].(*types.TypeName)
+						if ok && obj.Exported() && !obj.IsAlias() && spec.TypeParams == nil {
+							selected = append(selected, obj)
+						}
+					}
+				}
+			}
+		}
+		return selected, nil
+	}
+	for _, name := range names {
+		var obj *types.TypeName
+		for _, pkg := range pkgs {
+			if o, ok := pkg.Types.Scope().Lookup(name).(*types.TypeName); ok && !o.IsAlias() {
+				obj = o
+				break
+			}
+		}
+		if obj == nil {
+			return nil, fmt.Errorf("the type %s isn't declared by the packages", name)
+		}
+		selected = append(selected, obj)
+	}
+	return selected, nil
+}
+
+// reference returns a reference to the $defs entry of named, added with the types it uses when it's missing.
+func (r *reflector) reference(named *types.Named) (*orderedObject, error) {
+	obj := named.Obj()
+	if named.TypeArgs().Len() > 0 || named.TypeParams().Len() > 0 {
+		return nil, fmt.Errorf("the generic type %s isn't supported", obj.Name())
+	}
+	name, ok := r.names[obj]
+	if !ok {
+		name = r.definitionName(obj)
+		r.names[obj] = name
+		def := newOrderedObject()
+		// added ahead of the types it uses, which may reference it as well
+		r.defs.set(name, def)
+		if err := r.define(def, named); err != nil {
+			return nil, err
+		}
+	}
+	s := newOrderedObject()
+	s.set("$ref", "#/$defs/"+name)
+	return s, nil
+}
+
+// definitionName returns the name of obj in $defs, prefixed by its package when another type has the same name.
+func (r *reflector) definitionName(obj *types.TypeName) string {
+	name := obj.Name()
+	if _, ok := r.defs.values[name]; ok && obj.Pkg() != nil {
+		name = capitaliseFirstLetter(obj.Pkg().Name()) + name
+	}
+	return name
+}
+
+// define sets the schema of the struct or enum named in def.
+func (r *reflector) define(def *orderedObject, named *types.Named) error {
+	obj := named.Obj()
+	if doc := r.docs[obj.Pos()]; doc != "" {
+		def.set("description", doc)
+	}
+	if consts := r.consts[obj]; len(consts) > 0 {
+		s, err := r.schemaOf(named.Underlying())
+		if err != nil {
+			return fmt.Errorf("%s: %w", obj.Name(), err)
+		}
+		def.merge(s)
+		values := make([]any, 0, len(consts))
+		for _, c := range consts {
+			values = append(values, constantValue(c.Val()))
+		}
+		def.set("enum", values)
+		return nil
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return fmt.Errorf("%s: only structs and enums are defined", obj.Name())
+	}
+	if err := r.defineStruct(def, st); err != nil {
+		return fmt.Errorf("%s: %w", obj.Name(), err)
+	}
+	return nil
+}
+
+// defineStruct sets the properties of st in s.
+func (r *reflector) defineStruct(s *orderedObject, st *types.Struct) error {
+	properties := newOrderedObject()
+	var required []string
+	if err := r.addFields(properties, &required, st, false, 0); err != nil {
+		return err
+	}
+	s.set("type", "object")
+	if len(properties.keys) > 0 {
+		s.set("properties", properties)
+	}
+	if len(required) > 0 {
+		s.set("required", required)
+	}
+	return nil
+}
+
+// addFields adds the fields of st to properties, like encoding/json the fields of embedded structs without a name are
+// added too. Fields of an embedded struct pointer are optional, the shallower field wins when the names are the same.
+func (r *reflector) addFields(properties *orderedObject, required *[]string, st *types.Struct, optional bool, depth int) error {
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		name, omitempty, inline := fieldTag(reflect.StructTag(st.Tag(i)))
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous() && (name == "" || inline) {
+			t := field.Type()
+			pointer := false
+			if p, ok := t.(*types.Pointer); ok {
+				t, pointer = p.Elem(), true
+			}
+			if embedded, ok := t.Underlying().(*types.Struct); ok {
+				if err := r.addFields(properties, required, embedded, optional || pointer, depth+1); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.Exported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name()
+		}
+		if _, ok := properties.values[name]; ok && depth > 0 {
+			continue
+		}
+		s, err := r.schemaOf(field.Type())
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name(), err)
+		}
+		if doc := r.docs[field.Pos()]; doc != "" {
+			s.set("description", doc)
+		}
+		properties.set(name, s)
+		if _, pointer := field.Type().(*types.Pointer); !optional && !omitempty && !pointer {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// fieldTag returns the name of a field and its omitempty and inline options from its json tag, or from its bson tag
+// when there's none.
+func fieldTag(tag reflect.StructTag) (name string, omitempty, inline bool) {
+	value, ok := tag.Lookup("json")
+	if !ok {
+		value = tag.Get("bson")
+	}
+	if value == "-" {
+		return "-", false, false
+	}
+	options := strings.Split(value, ",")
+	for _, option := range options[1:] {
+		switch option {
+		case "omitempty":
+			omitempty = true
+		case "inline":
+			inline = true
+		}
+	}
+	return options[0], omitempty, inline
+}
+
+// wellKnownTypes are the JSON schemas of the standard types encoded differently from their Go type.
+var wellKnownTypes = map[string]func() *orderedObject{
+	"time.Time":     func() *orderedObject { return typeSchema("string", "date-time") },
+	"time.Duration": func() *orderedObject { return typeSchema("integer", "int64") },
+	"encoding/json.Number": func() *orderedObject {
+		return typeSchema("number", "decimal")
+	},
+	"encoding/json.RawMessage": newOrderedObject,
+	"net/netip.Addr":           func() *orderedObject { return typeSchema("string", "") },
+	"go.mongodb.org/mongo-driver/bson/primitive.ObjectID": func() *orderedObject {
+		return typeSchema("string", "")
+	},
+}
+
+// schemaOf returns the JSON schema of the values of t.
+func (r *reflector) schemaOf(t types.Type) (*orderedObject, error) {
+	t = types.Unalias(t)
+	switch t := t.(type) {
+	case *types.Named:
+		obj := t.Obj()
+		if obj.Pkg() != nil {
+			if schema, ok := wellKnownTypes[obj.Pkg().Path()+"."+obj.Name()]; ok {
+				return schema(), nil
+			}
+		}
+		methods := types.NewMethodSet(types.NewPointer(t))
+		switch {
+		case methods.Lookup(nil, "MarshalJSON") != nil:
+			// anything
+			return newOrderedObject(), nil
+		case methods.Lookup(nil, "MarshalText") != nil:
+			return typeSchema("string", ""), nil
+		}
+		if _, ok := t.Underlying().(*types.Struct); ok || len(r.consts[obj]) > 0 {
+			return r.reference(t)
+		}
+		return r.schemaOf(t.Underlying())
+	case *types.Basic:
+		return basicSchema(t)
+	case *types.Pointer:
+		return r.schemaOf(t.Elem())
+	case *types.Slice:
+		if b, ok := t.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte {
+			return typeSchema("string", "byte"), nil
+		}
+		return r.arraySchema(t.Elem())
+	case *types.Array:
+		s, err := r.arraySchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s.set("minItems", t.Len())
+		s.set("maxItems", t.Len())
+		return s, nil
+	case *types.Map:
+		values, err := r.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s := typeSchema("object", "")
+		if named, ok := types.Unalias(t.Key()).(*types.Named); ok && len(r.consts[named.Obj()]) > 0 {
+			names, err := r.reference(named)
+			if err != nil {
+				return nil, err
+			}
+			s.set("propertyNames", names)
+		}
+		s.set("additionalProperties", values)
+		return s, nil
+	case *types.Struct:
+		s := newOrderedObject()
+		if err := r.defineStruct(s, t); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case *types.Interface:
+		return newOrderedObject(), nil
+	}
+	return nil, fmt.Errorf("the type %s can't be encoded in JSON", t)
+}
+
+func (r *reflector) arraySchema(elem types.Type) (*orderedObject, error) {
+	items, err := r.schemaOf(elem)
+	if err != nil {
+		return nil, err
+	}
+	s := typeSchema("array", "")
+	s.set("items", items)
+	return s, nil
+}
+
+// basicSchema returns the JSON schema of a built-in type, the sized numbers have a format.
+func basicSchema(t *types.Basic) (*orderedObject, error) {
+	switch t.Kind() {
+	case types.Bool:
+		return typeSchema("boolean", ""), nil
+	case types.String:
+		return typeSchema("string", ""), nil
+	case types.Int, types.Int8, types.Int16:
+		return typeSchema("integer", ""), nil
+	case types.Int32:
+		return typeSchema("integer", "int32"), nil
+	case types.Int64:
+		return typeSchema("integer", "int64"), nil
+	case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64, types.Uintptr:
+		s := typeSchema("integer", "")
+		s.set("minimum", 0)
+		return s, nil
+	case types.Float32:
+		return typeSchema("number", "float"), nil
+	case types.Float64:
+		return typeSchema("number", ""), nil
+	}
+	return nil, fmt.Errorf("the type %s can't be encoded in JSON", t)
+}
+
+// typeSchema returns a schema of the JSON type typ, with a format unless it's empty.
+func typeSchema(typ, format string) *orderedObject {
+	s := newOrderedObject()
+	s.set("type", typ)
+	if format != "" {
+		s.set("format", format)
+	}
+	return s
+}
+
+// constantValue returns the JSON value of a constant.
+func constantValue(v constant.Value) any {
+	switch v.Kind() {
+	case constant.String:
+		return constant.StringVal(v)
+	case constant.Bool:
+		return constant.BoolVal(v)
+	case constant.Int:
+		if i, ok := constant.Int64Val(v); ok {
+			return i
+		}
+	}
+	f, _ := constant.Float64Val(v)
+	return f
+}
@@ -0,0 +1,85 @@
+package generate
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestThatGoTypesCanBeReflected(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/model\n\ngo 1.22\n",
+		"model.go": `package model
+
+import "time"
+
+// Status is the state of an order.
+type Status string
+
+const (
+	StatusOpen   Status = "open"
+	StatusClosed Status = "closed"
+)
+
+// Order is a purchase.
+type Order struct {
+	// ID identifies the order.
+	ID       string    ` + "`json:\"id\"`" + `
+	Created  time.Time ` + "`json:\"created\"`" + `
+	Status   Status    ` + "`json:\"status\"`" + `
+	Customer *Customer ` + "`json:\"customer,omitempty\"`" + `
+	Lines    []Line    ` + "`bson:\"lines,omitempty\"`" + `
+	Count    int64     ` + "`json:\"count\"`" + `
+	internal string
+}
+
+type Customer struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+type Line struct {
+	SKU string ` + "`json:\"sku\"`" + `
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := Reflect(ReflectOptions{Dir: dir, Types: []string{"Order"}}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, diagnostics, err := ParseWithOptions(string(b), &url.URL{Scheme: "file", Path: "/order.json"}, ParseOptions{Strict: true})
+	if err != nil || len(diagnostics) > 0 {
+		t.Fatalf("expected a valid schema, got %v %v:\n%s", diagnostics, err, b)
+	}
+	if keys := root.definitionKeys("$defs"); len(keys) != 4 || keys[0] != "Order" {
+		t.Errorf("expected Order and the types it uses, got %v", keys)
+	}
+
+	g := New(root)
+	if err := g.CreateTypes("", "main", false); err != nil {
+		t.Fatal("Failed to create structs: ", err)
+	}
+	order := g.Structs["Order"]
+	if order.Description != "Order is a purchase." {
+		t.Errorf("expected the doc comment as description, got %q", order.Description)
+	}
+	testField(order.Fields["Id"], "id", "Id", "string", true, t)
+	testField(order.Fields["Created"], "created", "Created", "time.Time", true, t)
+	testField(order.Fields["Status"], "status", "Status", "Status", true, t)
+	testField(order.Fields["Customer"], "customer", "Customer", "*Customer", false, t)
+	testField(order.Fields["Lines"], "lines", "Lines", "[]*Line", false, t)
+	testField(order.Fields["Count"], "count", "Count", "int64", true, t)
+	if len(order.Fields) != 6 {
+		t.Errorf("expected the exported fields only, got %v", order.Fields)
+	}
+	if status := g.Structs["Status"]; len(status.Enums) != 2 || status.Enums[0].Const != "open" {
+		t.Errorf("expected the constants as enum, got %+v", status.Enums)
+	}
+}
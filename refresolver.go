@@ -1,14 +1,16 @@
 package generate
 
 import (
-	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
 // RefResolver allows references to be resolved.
 type RefResolver struct {
+	// Loader maps the references that aren't found to the URI of the document they're read from, when set.
+	Loader  Loader
 	schemas []*Schema
 	//           k=uri     v=Schema
 	pathToSchema map[string]*Schema
@@ -49,43 +51,81 @@ func (r *RefResolver) GetPath(schema *Schema) string {
 	return getPath(schema.Parent, schema.PathElement)
 }
 
-// GetSchemaByReference returns the schema.
+// GetSchemaByReference returns the schema. References resolve against the $id of the schemas, then against the
+// document the schema was read from.
 func (r *RefResolver) GetSchemaByReference(rootPath string, schema *Schema) (*Schema, error) {
-	u, err := url.Parse(schema.GetRoot().ID())
+	root := schema.GetRoot()
+	u, err := schema.baseURI()
 	if err != nil {
-		return nil, err
+		return nil, newSchemaError(root, "$id", fmt.Sprintf("invalid $id %q", root.ID()), err)
 	}
 	ref, err := url.Parse(schema.Reference)
 	if err != nil {
-		return nil, err
+		return nil, newSchemaError(schema, "$ref", fmt.Sprintf("invalid reference %q", schema.Reference), err)
 	}
 	resolvedPath := u.ResolveReference(ref)
-	path, ok := r.pathToSchema[resolvedPath.String()]
-	if !ok {
-		str := fmt.Sprintf("file://%s%s", rootPath, ref.Path)
-		path, ok = r.pathToSchema[str]
-		if !ok {
-			return nil, errors.New("refresolver.GetSchemaByReference: reference not found: " + schema.Reference)
+	if path, ok := r.pathToSchema[resolvedPath.String()]; ok {
+		return path, nil
+	}
+
+	// the fragment is looked up in the document the reference resolves to
+	resolvedPath.Fragment = ""
+	documents := []string{resolvedPath.String()}
+	if ref.Scheme != "" || ref.Host != "" || ref.Path != "" {
+		document := root.documentURI()
+		// a file next to the document rather than to its $id
+		if d, err := url.Parse(document); err == nil && document != u.String() {
+			d = d.ResolveReference(ref)
+			d.Fragment = ""
+			documents = append(documents, d.String())
+		}
+		if r.Loader != nil {
+			if uri, ok := r.Loader.Resolve(document, schema.Reference); ok {
+				documents = append(documents, uri)
+			}
 		}
+		documents = append(documents, fmt.Sprintf("file://%s%s", rootPath, ref.Path))
 	}
-	return path, nil
+	for _, document := range documents {
+		if path, ok := r.schemaInDocument(document, ref.Fragment); ok {
+			return path, nil
+		}
+	}
+	return nil, newSchemaError(schema, "$ref", fmt.Sprintf("reference %q not found", schema.Reference), nil)
+}
+
+// schemaInDocument returns the schema at fragment, a JSON pointer or an anchor, of the document at uri.
+func (r *RefResolver) schemaInDocument(uri, fragment string) (*Schema, bool) {
+	document, ok := r.pathToSchema[uri]
+	if !ok {
+		return nil, false
+	}
+	if fragment == "" {
+		return document, true
+	}
+	if strings.HasPrefix(fragment, "/") {
+		return document.schemaAtPointer(fragment)
+	}
+	// anchors are registered under the $id of the document
+	anchor, err := document.baseURI()
+	if err != nil {
+		return nil, false
+	}
+	anchor.Fragment = fragment
+	schema, ok := r.pathToSchema[anchor.String()]
+	return schema, ok
 }
 
 func (r *RefResolver) mapPaths(schema *Schema) error {
-	rootURI := &url.URL{}
-	id := schema.ID()
-	if id == "" {
+	rootURI, err := schema.baseURI()
+	if err != nil {
+		return newSchemaError(schema, "$id", fmt.Sprintf("invalid $id %q", schema.ID()), err)
+	}
+	if rootURI.String() == "" {
 		if err := r.InsertURI("#", schema); err != nil {
 			return err
 		}
 	} else {
-		var err error
-		rootURI, err = url.Parse(id)
-		if err != nil {
-			return err
-		}
-		// ensure no fragment.
-		rootURI.Fragment = ""
 		if err := r.InsertURI(rootURI.String(), schema); err != nil {
 			return err
 		}
@@ -94,7 +134,15 @@ func (r *RefResolver) mapPaths(schema *Schema) error {
 			return err
 		}
 	}
-	r.updateURIs(schema, *rootURI, false, false)
+	// the document is found by the URI it was read from as well as by its $id
+	if schema.uri != "" && schema.uri != rootURI.String() {
+		if err := r.InsertURI(schema.uri, schema); err != nil {
+			return err
+		}
+	}
+	if err := r.updateURIs(schema, *rootURI, false, false); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -106,7 +154,7 @@ func (r *RefResolver) updateURIs(schema *Schema, baseURI url.URL, checkCurrentID
 		if id != "" {
 			newBase, err := url.Parse(id)
 			if err != nil {
-				return err
+				return newSchemaError(schema, "$id", fmt.Sprintf("invalid $id %q", id), err)
 			}
 			// if it's a JSON fragment and we're coming from part of the tree where the baseURI has changed, we need to
 			// ignore the fragment, since it won't be resolvable under the current baseURI.
@@ -129,13 +177,24 @@ func (r *RefResolver) updateURIs(schema *Schema, baseURI url.URL, checkCurrentID
 			}
 		}
 	}
-	for k, subSchema := range schema.Definitions {
-		newBaseURI := baseURI
-		newBaseURI.Fragment += "/$defs/" + k
-		if err := r.InsertURI(newBaseURI.String(), subSchema); err != nil {
+	if schema.Anchor != "" && !ignoreFragments {
+		anchorURI := baseURI
+		anchorURI.Fragment = schema.Anchor
+		if err := r.InsertURI(anchorURI.String(), schema); err != nil {
 			return err
 		}
-		r.updateURIs(subSchema, newBaseURI, true, ignoreFragments)
+	}
+	for keyword, defs := range schema.definitionsByKeyword() {
+		for k, subSchema := range defs {
+			newBaseURI := baseURI
+			newBaseURI.Fragment += "/" + keyword + "/" + k
+			if err := r.InsertURI(newBaseURI.String(), subSchema); err != nil {
+				return err
+			}
+			if err := r.updateURIs(subSchema, newBaseURI, true, ignoreFragments); err != nil {
+				return err
+			}
+		}
 	}
 	for k, subSchema := range schema.Properties {
 		newBaseURI := baseURI
@@ -143,25 +202,86 @@ func (r *RefResolver) updateURIs(schema *Schema, baseURI url.URL, checkCurrentID
 		if err := r.InsertURI(newBaseURI.String(), subSchema); err != nil {
 			return err
 		}
-		r.updateURIs(subSchema, newBaseURI, true, ignoreFragments)
+		if err := r.updateURIs(subSchema, newBaseURI, true, ignoreFragments); err != nil {
+			return err
+		}
+	}
+	for k, subSchema := range schema.PatternProperties {
+		newBaseURI := baseURI
+		newBaseURI.Fragment += "/patternProperties/" + escapeJSONPointer(k)
+		if err := r.updateURIs(subSchema, newBaseURI, true, ignoreFragments); err != nil {
+			return err
+		}
 	}
 	if schema.AdditionalProperties != nil {
 		newBaseURI := baseURI
 		newBaseURI.Fragment += "/additionalProperties"
-		r.updateURIs((*Schema)(schema.AdditionalProperties), newBaseURI, true, ignoreFragments)
+		if err := r.updateURIs((*Schema)(schema.AdditionalProperties), newBaseURI, true, ignoreFragments); err != nil {
+			return err
+		}
+	}
+	if schema.PropertyNames != nil {
+		newBaseURI := baseURI
+		newBaseURI.Fragment += "/propertyNames"
+		if err := r.updateURIs(schema.PropertyNames, newBaseURI, true, ignoreFragments); err != nil {
+			return err
+		}
 	}
 	if schema.Items != nil {
 		newBaseURI := baseURI
 		newBaseURI.Fragment += "/items"
-		r.updateURIs(schema.Items, newBaseURI, true, ignoreFragments)
+		if err := r.updateURIs(schema.Items, newBaseURI, true, ignoreFragments); err != nil {
+			return err
+		}
+	}
+	for i, subSchema := range schema.PrefixItems {
+		newBaseURI := baseURI
+		newBaseURI.Fragment += "/" + schema.prefixItemsKeyword() + "/" + strconv.Itoa(i)
+		if err := r.updateURIs(subSchema, newBaseURI, true, ignoreFragments); err != nil {
+			return err
+		}
+	}
+	if schema.AdditionalItems != nil {
+		newBaseURI := baseURI
+		newBaseURI.Fragment += "/" + schema.additionalItemsKeyword()
+		if err := r.updateURIs((*Schema)(schema.AdditionalItems), newBaseURI, true, ignoreFragments); err != nil {
+			return err
+		}
+	}
+	if schema.Contains != nil {
+		newBaseURI := baseURI
+		newBaseURI.Fragment += "/contains"
+		if err := r.updateURIs(schema.Contains, newBaseURI, true, ignoreFragments); err != nil {
+			return err
+		}
+	}
+	for keyword, subSchemas := range schema.combinatorsByKeyword() {
+		for i, subSchema := range subSchemas {
+			newBaseURI := baseURI
+			newBaseURI.Fragment += "/" + keyword + "/" + strconv.Itoa(i)
+			if err := r.updateURIs(subSchema, newBaseURI, true, ignoreFragments); err != nil {
+				return err
+			}
+		}
+	}
+	for keyword, subSchema := range schema.conditionsByKeyword() {
+		newBaseURI := baseURI
+		newBaseURI.Fragment += "/" + keyword
+		if err := r.updateURIs(subSchema, newBaseURI, true, ignoreFragments); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
 // InsertURI to the references.
 func (r *RefResolver) InsertURI(uri string, schema *Schema) error {
-	if _, ok := r.pathToSchema[uri]; ok {
-		return fmt.Errorf("attempted to add duplicate uri: %s/%s", schema.GetRoot().ID(), uri)
+	if existing, ok := r.pathToSchema[uri]; ok {
+		if existing == schema {
+			// a nested $id is mapped under its own and the enclosing base URI
+			return nil
+		}
+		return newSchemaError(schema, "", fmt.Sprintf("attempted to add duplicate uri: %s", uri), nil)
 	}
 	r.pathToSchema[uri] = schema
 	return nil
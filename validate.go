@@ -0,0 +1,714 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Constraints are the validation keywords of a value, checked by the generated Validate method.
+type Constraints struct {
+	MinLength *int
+	MaxLength *int
+	// Pattern is a regular expression supported by the Go regexp package.
+	Pattern string
+	// UnsupportedPattern is a regular expression Go can't compile, e.g. one using lookaheads.
+	UnsupportedPattern string
+
+	Minimum          *float64
+	Maximum          *float64
+	ExclusiveMinimum *float64
+	ExclusiveMaximum *float64
+	MultipleOf       *float64
+
+	MinItems    *int
+	MaxItems    *int
+	UniqueItems bool
+	// Items are the constraints of the array items.
+	Items *Constraints
+	// Contains are the constraints at least one array item meets, empty when any item does.
+	Contains *Constraints
+
+	MinProperties *int
+	MaxProperties *int
+	// PropertyNames are the constraints of the property names.
+	PropertyNames *Constraints
+	// Values are the constraints of the additional property values.
+	Values *Constraints
+
+	// Enum are the values allowed by "enum", or the value of "const", decoded from JSON. They are checked for the
+	// strings, numbers, booleans and interfaces only, the other types check them when they're unmarshaled.
+	Enum []any
+}
+
+// getConstraints returns the validation keywords of schema and of the schema it references, see Schema.siblingsApply.
+// It returns nil when there are none.
+func (g *Generator) getConstraints(rootPath string, schema *Schema) *Constraints {
+	return g.constraints(rootPath, schema, make(map[*Schema]bool))
+}
+
+// constraints is getConstraints, references to the schemas of referencing are skipped so that cycles end.
+func (g *Generator) constraints(rootPath string, schema *Schema, referencing map[*Schema]bool) *Constraints {
+	// the values of an x-go-type are opaque
+	if schema == nil || schema.extensionString(xGoType) != "" {
+		return nil
+	}
+	c := &Constraints{
+		MinLength:        schema.MinLength,
+		MaxLength:        schema.MaxLength,
+		Minimum:          schema.InclusiveMinimum(),
+		Maximum:          schema.InclusiveMaximum(),
+		ExclusiveMinimum: schema.ExclusiveMinimum(),
+		ExclusiveMaximum: schema.ExclusiveMaximum(),
+		MultipleOf:       schema.MultipleOf,
+		MinItems:         schema.MinItems,
+		MaxItems:         schema.MaxItems,
+		UniqueItems:      schema.UniqueItems,
+		Items:            g.constraints(rootPath, schema.Items, referencing),
+		MinProperties:    schema.MinProperties,
+		MaxProperties:    schema.MaxProperties,
+		PropertyNames:    g.constraints(rootPath, schema.PropertyNames, referencing),
+		Enum:             schema.allowedValues(),
+	}
+	if schema.AdditionalProperties != nil {
+		c.Values = g.constraints(rootPath, (*Schema)(schema.AdditionalProperties), referencing)
+	}
+	if schema.Contains != nil {
+		// a schema without constraints still requires an item
+		c.Contains = g.constraints(rootPath, schema.Contains, referencing)
+		if c.Contains == nil {
+			c.Contains = &Constraints{}
+		}
+	}
+	if schema.Pattern != "" {
+		if _, err := regexp.Compile(schema.Pattern); err == nil {
+			c.Pattern = schema.Pattern
+		} else {
+			c.UnsupportedPattern = schema.Pattern
+		}
+	}
+	// the keywords next to a reference add to the ones of the referenced schema, from 2019-09 onwards
+	if schema.Reference != "" && (c.isEmpty() || schema.siblingsApply()) {
+		refSchema, err := g.resolver.GetSchemaByReference(rootPath, schema)
+		if err == nil && refSchema != schema && !referencing[refSchema] {
+			referencing[refSchema] = true
+			c.inherit(g.constraints(rootPath, refSchema, referencing))
+			delete(referencing, refSchema)
+		}
+	}
+	if c.isEmpty() {
+		return nil
+	}
+	return c
+}
+
+// isEmpty returns true when c constrains nothing.
+func (c *Constraints) isEmpty() bool {
+	return reflect.DeepEqual(*c, Constraints{})
+}
+
+// allowedValues returns the values of "enum", or the value of "const", nil when neither is set.
+func (schema *Schema) allowedValues() []any {
+	if schema.ConstValue != nil {
+		var v any
+		if json.Unmarshal(schema.ConstValue, &v) == nil {
+			return []any{v}
+		}
+		return nil
+	}
+	return schema.EnumValue
+}
+
+// inherit sets the constraints of c that aren't set from ref, the constraints of a referenced schema.
+func (c *Constraints) inherit(ref *Constraints) {
+	if ref == nil {
+		return
+	}
+	own := *c
+	*c = *ref
+	if own.MinLength != nil {
+		c.MinLength = own.MinLength
+	}
+	if own.MaxLength != nil {
+		c.MaxLength = own.MaxLength
+	}
+	if own.Pattern != "" || own.UnsupportedPattern != "" {
+		c.Pattern, c.UnsupportedPattern = own.Pattern, own.UnsupportedPattern
+	}
+	if own.Minimum != nil {
+		c.Minimum = own.Minimum
+	}
+	if own.Maximum != nil {
+		c.Maximum = own.Maximum
+	}
+	if own.ExclusiveMinimum != nil {
+		c.ExclusiveMinimum = own.ExclusiveMinimum
+	}
+	if own.ExclusiveMaximum != nil {
+		c.ExclusiveMaximum = own.ExclusiveMaximum
+	}
+	if own.MultipleOf != nil {
+		c.MultipleOf = own.MultipleOf
+	}
+	if own.MinItems != nil {
+		c.MinItems = own.MinItems
+	}
+	if own.MaxItems != nil {
+		c.MaxItems = own.MaxItems
+	}
+	c.UniqueItems = own.UniqueItems || ref.UniqueItems
+	if own.MinProperties != nil {
+		c.MinProperties = own.MinProperties
+	}
+	if own.MaxProperties != nil {
+		c.MaxProperties = own.MaxProperties
+	}
+	if own.Enum != nil {
+		c.Enum = own.Enum
+	}
+	c.Items = inheritedConstraints(own.Items, ref.Items)
+	c.Contains = inheritedConstraints(own.Contains, ref.Contains)
+	c.PropertyNames = inheritedConstraints(own.PropertyNames, ref.PropertyNames)
+	c.Values = inheritedConstraints(own.Values, ref.Values)
+}
+
+// inheritedConstraints returns own with the constraints of ref it doesn't set, nil when both are.
+func inheritedConstraints(own, ref *Constraints) *Constraints {
+	if own == nil {
+		return ref
+	}
+	own.inherit(ref)
+	return own
+}
+
+// mapConstraints returns the constraints of a map of dynamic properties, nil if there are none.
+func mapConstraints(names, values *Constraints) *Constraints {
+	if names == nil && values == nil {
+		return nil
+	}
+	return &Constraints{PropertyNames: names, Values: values}
+}
+
+// validationWriter emits the checks of the Violations method of a struct.
+type validationWriter struct {
+	s        Struct
+	structs  map[string]Struct
+	imports  map[string]bool
+	patterns *bytes.Buffer
+	// unsupported lists the patterns that can't be checked
+	unsupported []string
+}
+
+func emitValidateCode(w io.Writer, s Struct, structs map[string]Struct, imports map[string]bool) {
+	imports["errors"] = true
+	vw := &validationWriter{s: s, structs: structs, imports: imports, patterns: new(bytes.Buffer)}
+
+	fmt.Fprintf(w, `
+// Validate checks the JSON schema constraints of %[1]s and returns all violations.
+func (strct *%[1]s) Validate() error {
+	return errors.Join(strct.Violations("")...)
+}
+
+// Violations returns the JSON schema constraint violations of %[1]s, path is the JSON pointer of strct.
+func (strct *%[1]s) Violations(path string) []error {
+	var errs []error
+`, s.Name)
+
+	fieldNames := getOrderedFieldNames(s.Fields)
+	if len(s.TupleFields) > 0 {
+		fieldNames = s.TupleFields
+	}
+	for _, fieldKey := range fieldNames {
+		f := s.Fields[fieldKey]
+		if f.JSONName == "-" {
+			continue
+		}
+		path := fmt.Sprintf("path + %q", "/"+escapeJSONPointer(f.JSONName))
+		// a nil pointer is null as well as missing
+		if f.Required && isNillable(f.Type) && !(f.Nullable && strings.HasPrefix(f.Type, "*")) {
+			vw.imports["fmt"] = true
+			fmt.Fprintf(w, `	if strct.%s == nil {
+		errs = append(errs, fmt.Errorf("%%s: required property is missing", %s))
+	}
+`, f.Name, path)
+		}
+		// the UnmarshalJSON of a constant type sets the constant, its zero value is missing unless it's the constant
+		if c, ok := vw.structs[f.Type]; ok && f.Required && c.ConstType != "" && !c.isZeroConst() {
+			vw.imports["fmt"] = true
+			fmt.Fprintf(w, `	if strct.%s != %sValue {
+		errs = append(errs, fmt.Errorf("%%s: required property is missing", %s))
+	}
+`, f.Name, f.Type, path)
+		}
+		checks := vw.checks("\t", "strct."+f.Name, f.Type, path, f.Constraints, f.Name, 0, 0)
+		if checks != "" && !f.Required && (strings.HasPrefix(f.Type, "[]") || strings.HasPrefix(f.Type, "map[")) {
+			// an absent optional array or object is valid
+			checks = fmt.Sprintf("\tif strct.%s != nil {\n%s\t}\n", f.Name, indentCode(checks))
+		}
+		fmt.Fprint(w, checks)
+	}
+	// values of patternProperties, additionalProperties and items following a tuple
+	for _, p := range s.PatternProperties {
+		f := s.Fields[p.Field]
+		fmt.Fprint(w, vw.checks("\t", "strct."+f.Name, f.Type, "path", f.Constraints, f.Name, 0, 0))
+	}
+	if f, ok := s.Fields["AdditionalProperties"]; ok {
+		fmt.Fprint(w, vw.checks("\t", "strct."+f.Name, f.Type, "path", f.Constraints, f.Name, 0, 0))
+	}
+	if f, ok := s.Fields["AdditionalItems"]; ok && len(s.TupleFields) > 0 {
+		fmt.Fprint(w, vw.checks("\t", "strct."+f.Name, f.Type, "path", nil, f.Name, 0, len(s.TupleFields)))
+	}
+
+	for _, c := range s.Conditions {
+		vw.emitCondition(w, c)
+	}
+	for _, u := range vw.unsupported {
+		fmt.Fprintf(w, "\t// %s: the pattern is not supported by the regexp package\n", u)
+	}
+	fmt.Fprintf(w, "	return errs\n")
+	fmt.Fprintf(w, "}\n")
+	if vw.patterns.Len() > 0 {
+		fmt.Fprintf(w, "\nvar (\n%s)\n", vw.patterns.String())
+	}
+}
+
+// checks returns the code validating expr of golang type typ against c, path is the expression of its JSON pointer.
+// The result is empty when there's nothing to check. offset shifts the indices of array items. name is the golang name
+// of the value, the names of the nested values are joined by underscores, e.g. Tags_Items, to name their regexps.
+func (vw *validationWriter) checks(indent, expr, typ, path string, c *Constraints, name string, depth, offset int) string {
+	buf := new(bytes.Buffer)
+	v := "v" + strconv.Itoa(depth)
+	switch {
+	case strings.HasPrefix(typ, "*"):
+		if vw.isValidatable(typ[1:]) {
+			fmt.Fprintf(buf, "%sif %s != nil {\n", indent, expr)
+			fmt.Fprintf(buf, "%s\terrs = append(errs, %s.Violations(%s)...)\n", indent, expr, path)
+			fmt.Fprintf(buf, "%s}\n", indent)
+			return buf.String()
+		}
+		inner := vw.checks(indent+"\t", v, typ[1:], path, c, name, depth+1, offset)
+		if inner == "" {
+			return ""
+		}
+		fmt.Fprintf(buf, "%sif %s != nil {\n", indent, expr)
+		fmt.Fprintf(buf, "%s\t%s := *%s\n", indent, v, expr)
+		buf.WriteString(inner)
+		fmt.Fprintf(buf, "%s}\n", indent)
+	case strings.HasPrefix(typ, "Nullable["):
+		inner := vw.checks(indent+"\t", v, strings.TrimSuffix(strings.TrimPrefix(typ, "Nullable["), "]"), path, c, name, depth+1, offset)
+		if inner == "" {
+			return ""
+		}
+		fmt.Fprintf(buf, "%sif %s, ok := %s[true]; ok {\n", indent, v, expr)
+		buf.WriteString(inner)
+		fmt.Fprintf(buf, "%s}\n", indent)
+	case strings.HasPrefix(typ, "[]"):
+		if c != nil {
+			vw.emitLengthChecks(buf, indent, "len("+expr+")", path, c.MinItems, c.MaxItems, "items")
+			if c.UniqueItems {
+				vw.imports["fmt"] = true
+				vw.imports["reflect"] = true
+				fmt.Fprintf(buf, `%[1]sfor i := range %[2]s {
+%[1]s	for j := i + 1; j < len(%[2]s); j++ {
+%[1]s		if reflect.DeepEqual(%[2]s[i], %[2]s[j]) {
+%[1]s			errs = append(errs, fmt.Errorf("%%s: items %%d and %%d must be unique", %[3]s, i, j))
+%[1]s		}
+%[1]s	}
+%[1]s}
+`, indent, expr, path)
+			}
+			if c.Contains != nil {
+				vw.emitContainsCheck(buf, indent, expr, typ[2:], path, c.Contains, name, depth)
+			}
+		}
+		var itemConstraints *Constraints
+		if c != nil {
+			itemConstraints = c.Items
+		}
+		i := "i" + strconv.Itoa(depth)
+		index := i
+		if offset > 0 {
+			index = fmt.Sprintf("%s+%d", i, offset)
+		}
+		itemPath := fmt.Sprintf("%s + \"/\" + strconv.Itoa(%s)", path, index)
+		inner := vw.checks(indent+"\t", v, typ[2:], itemPath, itemConstraints, name+"_Items", depth+1, 0)
+		if inner != "" {
+			vw.imports["strconv"] = true
+			fmt.Fprintf(buf, "%sfor %s, %s := range %s {\n", indent, i, v, expr)
+			buf.WriteString(inner)
+			fmt.Fprintf(buf, "%s}\n", indent)
+		}
+	case strings.HasPrefix(typ, "map["):
+		if c != nil {
+			vw.emitLengthChecks(buf, indent, "len("+expr+")", path, c.MinProperties, c.MaxProperties, "properties")
+		}
+		k := "k" + strconv.Itoa(depth)
+		valueType := typ[strings.Index(typ, "]")+1:]
+		valuePath := fmt.Sprintf("%s + \"/\" + strings.NewReplacer(\"~\", \"~0\", \"/\", \"~1\").Replace(string(%s))", path, k)
+		var keyChecks string
+		if c != nil && c.PropertyNames != nil {
+			keyChecks = vw.checks(indent+"\t", "string("+k+")", "string", valuePath, c.PropertyNames, name+"_Names", depth+1, 0)
+		}
+		var valueConstraints *Constraints
+		if c != nil {
+			valueConstraints = c.Values
+		}
+		inner := vw.checks(indent+"\t", v, valueType, valuePath, valueConstraints, name+"_Values", depth+1, 0)
+		if keyChecks != "" || inner != "" {
+			vw.imports["strings"] = true
+			if inner == "" {
+				fmt.Fprintf(buf, "%sfor %s := range %s {\n", indent, k, expr)
+			} else {
+				fmt.Fprintf(buf, "%sfor %s, %s := range %s {\n", indent, k, v, expr)
+			}
+			buf.WriteString(keyChecks)
+			buf.WriteString(inner)
+			fmt.Fprintf(buf, "%s}\n", indent)
+		}
+	case vw.isValidatable(typ):
+		fmt.Fprintf(buf, "%serrs = append(errs, %s.Violations(%s)...)\n", indent, expr, path)
+	case vw.isInterface(typ):
+		fmt.Fprintf(buf, "%sif v, ok := %s.(interface{ Violations(string) []error }); ok {\n", indent, expr)
+		fmt.Fprintf(buf, "%s\terrs = append(errs, v.Violations(%s)...)\n", indent, path)
+		fmt.Fprintf(buf, "%s}\n", indent)
+	case typ == "string":
+		if c == nil {
+			return ""
+		}
+		if c.MinLength != nil || c.MaxLength != nil {
+			vw.imports["unicode/utf8"] = true
+			vw.emitLengthChecks(buf, indent, "utf8.RuneCountInString("+expr+")", path, c.MinLength, c.MaxLength, "characters")
+		}
+		if c.Pattern != "" {
+			vw.imports["fmt"] = true
+			vw.imports["regexp"] = true
+			// the golang names have no underscores but leading ones, joining them with one is unambiguous
+			pattern := vw.s.Name + "_" + name + "Pattern"
+			fmt.Fprintf(vw.patterns, "\t%s = regexp.MustCompile(%s)\n", pattern, strconv.Quote(c.Pattern))
+			fmt.Fprintf(buf, "%sif !%s.MatchString(%s) {\n", indent, pattern, expr)
+			fmt.Fprintf(buf, "%s\terrs = append(errs, fmt.Errorf(\"%%s: must match the pattern %%s\", %s, %s))\n", indent, path, pattern)
+			fmt.Fprintf(buf, "%s}\n", indent)
+		}
+		if c.UnsupportedPattern != "" {
+			vw.unsupported = append(vw.unsupported, fmt.Sprintf("%s %q", name, c.UnsupportedPattern))
+		}
+	case isNumeric(typ):
+		if c == nil {
+			return ""
+		}
+		number := "float64(" + expr + ")"
+		vw.emitBoundCheck(buf, indent, number, "<", c.Minimum, path, "at least")
+		vw.emitBoundCheck(buf, indent, number, "<=", c.ExclusiveMinimum, path, "greater than")
+		vw.emitBoundCheck(buf, indent, number, ">", c.Maximum, path, "at most")
+		vw.emitBoundCheck(buf, indent, number, ">=", c.ExclusiveMaximum, path, "less than")
+		if c.MultipleOf != nil && *c.MultipleOf != 0 {
+			vw.imports["fmt"] = true
+			vw.imports["math"] = true
+			m := formatNumber(*c.MultipleOf)
+			// the quotient of decimal numbers is rarely exact in binary, e.g. 0.3 / 0.1, a relative error is tolerated
+			fmt.Fprintf(buf, "%sif q := %s / %s; math.Abs(q-math.Round(q)) > 1e-9*math.Max(1, math.Abs(q)) {\n", indent, number, m)
+			fmt.Fprintf(buf, "%s\terrs = append(errs, fmt.Errorf(\"%%s: must be a multiple of %s\", %s))\n", indent, m, path)
+			fmt.Fprintf(buf, "%s}\n", indent)
+		}
+	}
+	if c != nil && len(c.Enum) > 0 {
+		vw.emitEnumCheck(buf, indent, expr, typ, path, c.Enum)
+	}
+	return buf.String()
+}
+
+// emitContainsCheck checks that an item of the array expr of itemType meets c, the constraints of "contains".
+func (vw *validationWriter) emitContainsCheck(w io.Writer, indent, expr, itemType, path string, c *Constraints, name string, depth int) {
+	vw.imports["fmt"] = true
+	v := "v" + strconv.Itoa(depth)
+	// the values of enum items, and of pointers to them, are compared as their underlying type
+	item := v
+	if s, ok := vw.structs[strings.TrimPrefix(itemType, "*")]; ok && s.EnumType != "" {
+		if strings.HasPrefix(itemType, "*") {
+			item, itemType = "(*"+s.EnumType+")("+v+")", "*"+s.EnumType
+		} else {
+			item, itemType = s.EnumType+"("+v+")", s.EnumType
+		}
+	}
+	// the checks append to the errs of the loop, an item without errors meets c
+	inner := vw.checks(indent+"\t", item, itemType, path, c, name+"_Contains", depth+1, 0)
+	if inner == "" {
+		fmt.Fprintf(w, "%sif len(%s) == 0 {\n", indent, expr)
+		fmt.Fprintf(w, "%s\terrs = append(errs, fmt.Errorf(\"%%s: must contain an item\", %s))\n", indent, path)
+		fmt.Fprintf(w, "%s}\n", indent)
+		return
+	}
+	found := "found" + strconv.Itoa(depth)
+	fmt.Fprintf(w, "%s%s := false\n", indent, found)
+	fmt.Fprintf(w, "%sfor _, %s := range %s {\n", indent, v, expr)
+	fmt.Fprintf(w, "%s\tvar errs []error\n", indent)
+	fmt.Fprint(w, inner)
+	fmt.Fprintf(w, "%s\tif len(errs) == 0 {\n", indent)
+	fmt.Fprintf(w, "%s\t\t%s = true\n", indent, found)
+	fmt.Fprintf(w, "%s\t\tbreak\n", indent)
+	fmt.Fprintf(w, "%s\t}\n", indent)
+	fmt.Fprintf(w, "%s}\n", indent)
+	fmt.Fprintf(w, "%sif !%s {\n", indent, found)
+	fmt.Fprintf(w, "%s\terrs = append(errs, fmt.Errorf(\"%%s: must contain an item matching the contains schema\", %s))\n", indent, path)
+	fmt.Fprintf(w, "%s}\n", indent)
+}
+
+// emitCondition checks the properties required by the "then" and "else" schemas of c.
+func (vw *validationWriter) emitCondition(w io.Writer, c Condition) {
+	then, otherwise := vw.requiredChecks("\t\t", c.Then), vw.requiredChecks("\t\t", c.Else)
+	if then == "" && otherwise == "" {
+		return
+	}
+	condition, ok := vw.conditionExpr(c)
+	if !ok {
+		fmt.Fprintf(w, "\t// the condition %s is not supported, its required properties are not checked\n", c.Path)
+		return
+	}
+	fmt.Fprintf(w, "\t// %s\n", c.Path)
+	if then == "" {
+		fmt.Fprintf(w, "\tif !(%s) {\n%s\t}\n", condition, otherwise)
+		return
+	}
+	fmt.Fprintf(w, "\tif %s {\n%s\t}", condition, then)
+	if otherwise != "" {
+		fmt.Fprintf(w, " else {\n%s\t}", otherwise)
+	}
+	fmt.Fprintln(w)
+}
+
+// requiredChecks returns the code checking the presence of the properties.
+func (vw *validationWriter) requiredChecks(indent string, properties []string) string {
+	buf := new(bytes.Buffer)
+	for _, p := range properties {
+		f, ok := vw.fieldByJSONName(p)
+		if !ok || !isNillable(f.Type) {
+			continue
+		}
+		vw.imports["fmt"] = true
+		fmt.Fprintf(buf, "%sif strct.%s == nil {\n", indent, f.Name)
+		fmt.Fprintf(buf, "%s\terrs = append(errs, fmt.Errorf(\"%%s: required property is missing\", path + %q))\n", indent, "/"+escapeJSONPointer(p))
+		fmt.Fprintf(buf, "%s}\n", indent)
+	}
+	return buf.String()
+}
+
+// conditionExpr returns the golang expression testing the "if" schema of c, false when it can't be tested.
+func (vw *validationWriter) conditionExpr(c Condition) (string, bool) {
+	if c.Unsupported {
+		return "", false
+	}
+	var terms []string
+	for _, test := range c.If {
+		f, ok := vw.fieldByJSONName(test.Property)
+		if !ok {
+			return "", false
+		}
+		expr := "strct." + f.Name
+		if len(test.Values) == 0 {
+			if test.Required && isNillable(f.Type) {
+				terms = append(terms, expr+" != nil")
+			}
+			continue
+		}
+		typ := f.Type
+		if strings.HasPrefix(typ, "*") {
+			typ = typ[1:]
+			expr = "*" + expr
+		}
+		kind := vw.underlyingType(typ)
+		var values []string
+		for _, v := range test.Values {
+			literal, ok := literalOfKind(v, kind)
+			if !ok {
+				return "", false
+			}
+			values = append(values, expr+" == "+literal)
+		}
+		term := strings.Join(values, " || ")
+		if strings.HasPrefix(f.Type, "*") && test.Required {
+			term = fmt.Sprintf("strct.%s != nil && (%s)", f.Name, term)
+		} else if strings.HasPrefix(f.Type, "*") {
+			term = fmt.Sprintf("(strct.%s == nil || %s)", f.Name, term)
+		} else if len(values) > 1 {
+			term = "(" + term + ")"
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 0 {
+		return "true", true
+	}
+	return strings.Join(terms, " && "), true
+}
+
+// underlyingType returns the built-in type of typ, following the generated enum and const types.
+func (vw *validationWriter) underlyingType(typ string) string {
+	if s, ok := vw.structs[typ]; ok {
+		if s.EnumType != "" {
+			return s.EnumType
+		}
+		return s.ConstType
+	}
+	return typ
+}
+
+// literalOfKind returns the golang literal of a JSON value compared to a value of the built-in type kind.
+func literalOfKind(v any, kind string) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v), kind == "string"
+	case bool:
+		return strconv.FormatBool(v), kind == "bool"
+	case float64:
+		if kind == "float32" || kind == "float64" {
+			return formatNumber(v), true
+		}
+		return formatNumber(v), isNumeric(kind) && v == math.Trunc(v)
+	}
+	return "", false
+}
+
+func (vw *validationWriter) fieldByJSONName(name string) (Field, bool) {
+	for _, f := range vw.s.Fields {
+		if f.JSONName == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+func (vw *validationWriter) emitLengthChecks(w io.Writer, indent, length, path string, min, max *int, unit string) {
+	if min != nil && *min > 0 {
+		vw.imports["fmt"] = true
+		fmt.Fprintf(w, "%sif %s < %d {\n", indent, length, *min)
+		fmt.Fprintf(w, "%s\terrs = append(errs, fmt.Errorf(\"%%s: must have at least %d %s\", %s))\n", indent, *min, unit, path)
+		fmt.Fprintf(w, "%s}\n", indent)
+	}
+	if max != nil {
+		vw.imports["fmt"] = true
+		fmt.Fprintf(w, "%sif %s > %d {\n", indent, length, *max)
+		fmt.Fprintf(w, "%s\terrs = append(errs, fmt.Errorf(\"%%s: must have at most %d %s\", %s))\n", indent, *max, unit, path)
+		fmt.Fprintf(w, "%s}\n", indent)
+	}
+}
+
+func (vw *validationWriter) emitBoundCheck(w io.Writer, indent, number, violated string, bound *float64, path, description string) {
+	if bound == nil {
+		return
+	}
+	vw.imports["fmt"] = true
+	b := formatNumber(*bound)
+	fmt.Fprintf(w, "%sif %s %s %s {\n", indent, number, violated, b)
+	fmt.Fprintf(w, "%s\terrs = append(errs, fmt.Errorf(\"%%s: must be %s %s\", %s))\n", indent, description, b, path)
+	fmt.Fprintf(w, "%s}\n", indent)
+}
+
+// emitEnumCheck checks that expr of golang type typ is one of values, the values of "enum" or "const". Values of
+// another JSON type never match.
+func (vw *validationWriter) emitEnumCheck(w io.Writer, indent, expr, typ, path string, values []any) {
+	dynamic := typ == "interface{}"
+	if typ != "string" && typ != "bool" && !isNumeric(typ) && !dynamic {
+		return
+	}
+	var conditions, literals []string
+	for _, value := range values {
+		switch value := value.(type) {
+		case string:
+			literals = append(literals, strconv.Quote(value))
+			if typ == "string" || dynamic {
+				conditions = append(conditions, expr+" == "+strconv.Quote(value))
+			}
+		case float64:
+			literals = append(literals, formatNumber(value))
+			if isNumeric(typ) {
+				conditions = append(conditions, "float64("+expr+") == "+formatNumber(value))
+			} else if dynamic {
+				conditions = append(conditions, expr+" == float64("+formatNumber(value)+")")
+			}
+		case bool:
+			literals = append(literals, strconv.FormatBool(value))
+			if typ == "bool" || dynamic {
+				conditions = append(conditions, expr+" == "+strconv.FormatBool(value))
+			}
+		case nil:
+			literals = append(literals, "null")
+			if dynamic {
+				conditions = append(conditions, expr+" == nil")
+			}
+		default:
+			// objects and arrays are unmarshaled as maps and slices, which aren't comparable
+			return
+		}
+	}
+	vw.imports["fmt"] = true
+	message := "must be " + literals[0]
+	if len(literals) > 1 {
+		message = "must be one of " + strings.Join(literals, ", ")
+	}
+	// none of the values has the type of expr
+	condition := "true"
+	if len(conditions) > 0 {
+		condition = "!(" + strings.Join(conditions, " || ") + ")"
+	}
+	fmt.Fprintf(w, "%sif %s {\n", indent, condition)
+	fmt.Fprintf(w, "%s\terrs = append(errs, fmt.Errorf(%s, %s))\n", indent, strconv.Quote("%s: "+strings.ReplaceAll(message, "%", "%%")), path)
+	fmt.Fprintf(w, "%s}\n", indent)
+}
+
+// isValidatable returns true when typ is a generated struct with a Violations method.
+func (vw *validationWriter) isValidatable(typ string) bool {
+	s, ok := vw.structs[typ]
+	return ok && hasViolations(s)
+}
+
+// isInterface returns true when typ is a generated interface of "oneOf" alternatives.
+func (vw *validationWriter) isInterface(typ string) bool {
+	s, ok := vw.structs[typ]
+	return ok && s.Func.Name != ""
+}
+
+// hasViolations returns true when a Violations method is generated for s.
+func hasViolations(s Struct) bool {
+	return len(s.Enums) == 0 && s.Func.Name == "" && len(s.Fields) > 0
+}
+
+// isZeroConst returns true when the constant of s is the zero value of its type, which can't tell it's missing.
+func (s Struct) isZeroConst() bool {
+	switch s.ConstValue {
+	case "", 0, false:
+		return true
+	}
+	return s.ConstType == "struct{}"
+}
+
+func isNillable(typ string) bool {
+	return strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[") ||
+		strings.HasPrefix(typ, "Nullable[") || typ == "interface{}"
+}
+
+func isNumeric(typ string) bool {
+	switch typ {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		return true
+	}
+	return false
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// indentCode indents every line of code by a tab.
+func indentCode(code string) string {
+	return "\t" + strings.ReplaceAll(strings.TrimSuffix(code, "\n"), "\n", "\n\t") + "\n"
+}
+
+// escapeJSONPointer escapes a key to be used as a JSON pointer segment.
+func escapeJSONPointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
@@ -0,0 +1,260 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// isYAML returns true when the file extension denotes a YAML document.
+func isYAML(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// yamlDocument is a single YAML document converted to JSON, or a JSON document as it is.
+type yamlDocument struct {
+	JSON []byte
+	// positions of the JSON values in the YAML source, ordered by offset, nil for a JSON document
+	positions []yamlPosition
+}
+
+type yamlPosition struct {
+	offset, line, column int
+}
+
+// lineAndCharacter returns the YAML line and column of the JSON value at offset.
+func (doc yamlDocument) lineAndCharacter(offset int) (line int, character int, err error) {
+	if offset < 0 || offset > len(doc.JSON) {
+		return 0, 0, fmt.Errorf("couldn't find offset %d in %d bytes", offset, len(doc.JSON))
+	}
+	// the last value starting at or before the offset
+	i := sort.Search(len(doc.positions), func(i int) bool {
+		return doc.positions[i].offset > offset
+	})
+	if i == 0 {
+		return 0, 0, fmt.Errorf("couldn't find offset %d in %d bytes", offset, len(doc.JSON))
+	}
+	p := doc.positions[i-1]
+	return p.line, p.column, nil
+}
+
+// position returns the line and column in the file of the JSON value at offset.
+func (doc yamlDocument) position(offset int) (line int, column int, err error) {
+	if doc.positions == nil {
+		return lineAndColumn(doc.JSON, offset)
+	}
+	return doc.lineAndCharacter(offset)
+}
+
+// yamlToJSON converts every document of a YAML stream to JSON.
+func yamlToJSON(b []byte) ([]yamlDocument, error) {
+	var docs []yamlDocument
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	for {
+		var n yaml.Node
+		err := dec.Decode(&n)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n.Kind == yaml.DocumentNode && len(n.Content) == 0 {
+			// empty document, e.g. a trailing "---"
+			continue
+		}
+		c := &yamlConverter{}
+		if err := c.convert(&n, 0); err != nil {
+			return nil, err
+		}
+		docs = append(docs, yamlDocument{JSON: c.buf.Bytes(), positions: c.positions})
+	}
+	if len(docs) == 0 {
+		return nil, errors.New("no YAML document found")
+	}
+	return docs, nil
+}
+
+// yamlMaxDepth guards against alias loops.
+const yamlMaxDepth = 1000
+
+type yamlConverter struct {
+	buf       bytes.Buffer
+	positions []yamlPosition
+}
+
+func (c *yamlConverter) mark(n *yaml.Node) {
+	c.positions = append(c.positions, yamlPosition{offset: c.buf.Len(), line: n.Line, column: n.Column})
+}
+
+func (c *yamlConverter) convert(n *yaml.Node, depth int) error {
+	if depth > yamlMaxDepth {
+		return fmt.Errorf("YAML nesting too deep at line %d, column %d", n.Line, n.Column)
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		return c.convert(n.Content[0], depth+1)
+	case yaml.AliasNode:
+		return c.convert(n.Alias, depth+1)
+	case yaml.MappingNode:
+		pairs, err := mergedPairs(n, depth)
+		if err != nil {
+			return err
+		}
+		c.mark(n)
+		c.buf.WriteByte('{')
+		for i := 0; i+1 < len(pairs); i += 2 {
+			if i > 0 {
+				c.buf.WriteByte(',')
+			}
+			k, v := pairs[i], pairs[i+1]
+			c.mark(k)
+			key, err := json.Marshal(k.Value)
+			if err != nil {
+				return err
+			}
+			c.buf.Write(key)
+			c.buf.WriteByte(':')
+			if err := c.convert(v, depth+1); err != nil {
+				return err
+			}
+		}
+		c.buf.WriteByte('}')
+	case yaml.SequenceNode:
+		c.mark(n)
+		c.buf.WriteByte('[')
+		for i, v := range n.Content {
+			if i > 0 {
+				c.buf.WriteByte(',')
+			}
+			if err := c.convert(v, depth+1); err != nil {
+				return err
+			}
+		}
+		c.buf.WriteByte(']')
+	case yaml.ScalarNode:
+		c.mark(n)
+		var v interface{}
+		switch n.ShortTag() {
+		case "!!str", "!!timestamp", "!!binary":
+			// keep the text as written, e.g. dates are not converted to RFC 3339
+			v = n.Value
+		default:
+			if err := n.Decode(&v); err != nil {
+				return err
+			}
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("YAML value %q at line %d, column %d can't be converted to JSON: %v", n.Value, n.Line, n.Column, err)
+		}
+		c.buf.Write(b)
+	default:
+		return fmt.Errorf("unsupported YAML node at line %d, column %d", n.Line, n.Column)
+	}
+	return nil
+}
+
+// mergedPairs returns the keys and values of the mapping n, alternating, with its merge keys ("<<") expanded: the keys
+// of the merged mappings take the place of the merge key unless n sets them, the first mapping merging a key wins.
+func mergedPairs(n *yaml.Node, depth int) ([]*yaml.Node, error) {
+	if depth > yamlMaxDepth {
+		return nil, fmt.Errorf("YAML nesting too deep at line %d, column %d", n.Line, n.Column)
+	}
+	own := make(map[string]bool)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].ShortTag() != "!!merge" {
+			own[n.Content[i].Value] = true
+		}
+	}
+	var pairs []*yaml.Node
+	merged := make(map[string]bool)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if k.ShortTag() != "!!merge" {
+			pairs = append(pairs, k, v)
+			continue
+		}
+		sources := []*yaml.Node{v}
+		if resolveAlias(v).Kind == yaml.SequenceNode {
+			sources = resolveAlias(v).Content
+		}
+		for _, source := range sources {
+			source = resolveAlias(source)
+			if source.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("the YAML merge key at line %d, column %d doesn't refer to mappings", k.Line, k.Column)
+			}
+			sourcePairs, err := mergedPairs(source, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			for j := 0; j+1 < len(sourcePairs); j += 2 {
+				if key := sourcePairs[j].Value; !own[key] && !merged[key] {
+					merged[key] = true
+					pairs = append(pairs, sourcePairs[j], sourcePairs[j+1])
+				}
+			}
+		}
+	}
+	return pairs, nil
+}
+
+// resolveAlias returns the node n refers to when it's an alias, n otherwise.
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// yamlErrorLineAndCharacter extracts the position of a YAML syntax error. The YAML parser only reports the line, so
+// the character is the first non-blank character of that line.
+func yamlErrorLineAndCharacter(b []byte, err error) (line int, character int, lcErr error) {
+	m := yamlErrorLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0, 0, fmt.Errorf("no line found in %q", err.Error())
+	}
+	line, _ = strconv.Atoi(m[1])
+	lines := bytes.Split(b, []byte("\n"))
+	if line < 1 || line > len(lines) {
+		return 0, 0, fmt.Errorf("couldn't find line %d in %d lines", line, len(lines))
+	}
+	character = len(lines[line-1]) - len(bytes.TrimLeft(lines[line-1], " \t")) + 1
+	return line, character, nil
+}
+
+// yamlSyntaxError describes a YAML syntax error with its position in the file.
+func yamlSyntaxError(path string, b []byte, err error) error {
+	e := &SchemaError{File: path, Message: "cannot parse YAML schema due to a syntax error", Err: err}
+	if line, character, lcErr := yamlErrorLineAndCharacter(b, err); lcErr == nil {
+		e.Line, e.Column = line, character
+	}
+	return e
+}
+
+// readDocuments returns the JSON documents of an input file, converting YAML files by extension.
+func readDocuments(path string, b []byte) ([]yamlDocument, error) {
+	if !isYAML(path) {
+		return []yamlDocument{{JSON: b}}, nil
+	}
+	docs, err := yamlToJSON(b)
+	if err != nil {
+		return nil, yamlSyntaxError(path, b, err)
+	}
+	return docs, nil
+}
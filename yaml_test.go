@@ -0,0 +1,161 @@
+package generate
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestThatYAMLDocumentsAreConvertedToJSON(t *testing.T) {
+	in := `title: Customer
+type: object
+properties:
+  created:
+    type: string
+    default: 2001-12-14
+  count: {type: integer, minimum: 1}
+---
+title: Order
+`
+	docs, err := yamlToJSON([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(docs))
+	}
+
+	var actual map[string]interface{}
+	if err := json.Unmarshal(docs[0].JSON, &actual); err != nil {
+		t.Fatalf("the converted document is not valid JSON: %v", err)
+	}
+	created := actual["properties"].(map[string]interface{})["created"].(map[string]interface{})
+	if created["default"] != "2001-12-14" {
+		t.Errorf("expected timestamps to be kept as written, got %v", created["default"])
+	}
+	if string(docs[1].JSON) != `{"title":"Order"}` {
+		t.Errorf("unexpected second document %s", docs[1].JSON)
+	}
+}
+
+func TestThatYAMLMergeKeysAreExpanded(t *testing.T) {
+	in := `base: &base
+  type: string
+  description: base
+other: &other
+  minLength: 1
+  description: other
+name:
+  <<: [*base, *other]
+  description: name
+`
+	docs, err := yamlToJSON([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual map[string]map[string]interface{}
+	if err := json.Unmarshal(docs[0].JSON, &actual); err != nil {
+		t.Fatalf("the converted document is not valid JSON: %v", err)
+	}
+	expected := map[string]interface{}{"type": "string", "minLength": 1.0, "description": "name"}
+	if !reflect.DeepEqual(actual["name"], expected) {
+		t.Errorf("expected %v, got %v", expected, actual["name"])
+	}
+
+	_, err = readDocuments("merge.yaml", []byte("a: 1\nb:\n  <<: *x\n"))
+	if err == nil {
+		t.Fatal("expected an error for an unknown anchor")
+	}
+	_, err = readDocuments("merge.yaml", []byte("a: &a 1\nb:\n  <<: *a\n"))
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.Line != 3 {
+		t.Errorf("expected an error at line 3 for a merge key of a scalar, got %v", err)
+	}
+}
+
+func TestYAMLLineAndCharacterFromOffset(t *testing.T) {
+	in := `title: Customer
+properties:
+  name:
+    description: [1, 2]
+`
+	docs, err := yamlToJSON([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := docs[0]
+	offset := strings.Index(string(doc.JSON), "[1,2]")
+	line, character, err := doc.lineAndCharacter(offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != 4 || character != 18 {
+		t.Errorf("expected 4:18, but got %d:%d", line, character)
+	}
+	if _, _, err := doc.lineAndCharacter(len(doc.JSON) + 1); err == nil {
+		t.Error("expected an error for an offset outside of the document")
+	}
+}
+
+func TestThatYAMLInputFilesCanBeRead(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schemas.yaml")
+	in := `$schema: http://json-schema.org/draft-07/schema#
+title: Customer
+type: object
+---
+$schema: http://json-schema.org/draft-07/schema#
+title: Order
+type: object
+`
+	if err := os.WriteFile(path, []byte(in), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	schemas, err := ReadInputFiles([]AnalysisFile{{Root: true, Path: path}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schemas) != 2 {
+		t.Fatalf("expected a schema per YAML document, got %d", len(schemas))
+	}
+	if schemas[0].Title != "Customer" || schemas[1].Title != "Order" {
+		t.Errorf("unexpected titles %q and %q", schemas[0].Title, schemas[1].Title)
+	}
+	if schemas[0].documentURI() == schemas[1].documentURI() {
+		t.Errorf("expected the documents to have different URIs, but both are %q", schemas[0].documentURI())
+	}
+}
+
+func TestThatYAMLErrorsReportTheLine(t *testing.T) {
+	dir := t.TempDir()
+	syntax := filepath.Join(dir, "syntax.yaml")
+	if err := os.WriteFile(syntax, []byte("title: x\n  foo: : bar\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	typ := filepath.Join(dir, "type.yml")
+	if err := os.WriteFile(typ, []byte("$schema: x\nproperties:\n  name:\n    title: [1]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: syntax, expected: "line 2, character 3"},
+		{path: typ, expected: "line 4"},
+	}
+	for _, test := range tests {
+		_, err := ReadInputFiles([]AnalysisFile{{Root: true, Path: test.path}}, false)
+		if err == nil {
+			t.Fatalf("%s: expected an error", test.path)
+		}
+		if !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expected the error to contain %q, got %q", test.path, test.expected, err.Error())
+		}
+	}
+}